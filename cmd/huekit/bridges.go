@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/dj95/huekit/pkg/homekit"
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// bridgeConfig Configuration of a single hue bridge
+type bridgeConfig struct {
+	Name    string `mapstructure:"name"`
+	Address string `mapstructure:"address"`
+	API     string `mapstructure:"api"`
+}
+
+// label Return the name of the bridge in messages
+func (c bridgeConfig) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.Address
+}
+
+// connectBridges Connect to all configured bridges and authenticate,
+// if no authentication is saved in the store. Without a list of bridges,
+// the single bridge of bridge_address and bridge_api is used.
+func connectBridges(backend store.Store) ([]homekit.HueBridge, error) {
+	var configs []bridgeConfig
+
+	// read the list of bridges
+	if err := viper.UnmarshalKey("bridges", &configs); err != nil {
+		return nil, err
+	}
+
+	// use the single bridge, which keeps its keys unprefixed in the
+	// store for compatibility
+	prefixed := len(configs) > 0
+
+	if !prefixed {
+		configs = []bridgeConfig{{
+			Address: viper.GetString("bridge_address"),
+			API:     viper.GetString("bridge_api"),
+		}}
+	}
+
+	var bridges []homekit.HueBridge
+
+	for _, config := range configs {
+		bridgeStore := backend
+		key := ""
+
+		// every bridge of the list saves its username and certificate
+		// under its own keys. The name is the key, as the address of a
+		// bridge can change.
+		if prefixed {
+			if config.Name == "" {
+				return nil, fmt.Errorf("the bridge '%s' needs a name", config.Address)
+			}
+
+			// bridges without name were saved with their address
+			if err := migrateBridge(backend, config.Address, config.Name); err != nil {
+				return nil, fmt.Errorf("cannot migrate bridge '%s': %w", config.Name, err)
+			}
+
+			key = config.Name
+			bridgeStore = store.NewPrefix(backend, bridgePrefix(key))
+		}
+
+		// use the legacy api by default
+		if config.API == "" {
+			config.API = "v1"
+		}
+
+		// create a new bridge connection
+		bridge, err := newBridge(config.API, config.Address, bridgeStore)
+
+		// error handling
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect to bridge '%s': %w", config.label(), err)
+		}
+
+		// serve the lights from a cache in order to reduce the
+		// requests against the bridge
+		if ttl := viper.GetDuration("cache_ttl"); ttl > 0 {
+			bridge = hue.NewCache(bridge, ttl)
+		}
+
+		bridges = append(bridges, homekit.HueBridge{
+			Key:    key,
+			Bridge: bridge,
+		})
+	}
+
+	return bridges, nil
+}
+
+// migrateBridge Move the keys of a bridge, that was saved with its address,
+// to its name. Nothing is moved, once the name has keys of its own.
+func migrateBridge(backend store.Store, address, name string) error {
+	if address == "" || address == name {
+		return nil
+	}
+
+	current, err := backend.Keys(bridgePrefix(name))
+
+	// error handling
+	if err != nil || len(current) > 0 {
+		return err
+	}
+
+	legacy, err := backend.Keys(bridgePrefix(address))
+
+	// error handling
+	if err != nil || len(legacy) == 0 {
+		return err
+	}
+
+	log.Infof("moving the saved keys of bridge '%s' to its name '%s'", address, name)
+
+	// copy the username, certificate and id of the bridge
+	for _, key := range legacy {
+		value, err := backend.Get(key)
+
+		// error handling
+		if err != nil {
+			return err
+		}
+
+		if err := backend.Set(bridgePrefix(name)+strings.TrimPrefix(key, bridgePrefix(address)), value); err != nil {
+			return err
+		}
+	}
+
+	// keep the accessory ids and exclusions of the lights
+	return homekit.MigrateBridge(backend, address, name)
+}
+
+// bridgePrefix Return the prefix of the keys of the bridge in the store
+func bridgePrefix(key string) string {
+	return "bridges/" + key + "/"
+}
+
+// newBridge Create the bridge for the configured api version
+func newBridge(api, address string, store store.Store) (hue.Bridger, error) {
+	switch api {
+	case "v1":
+		return hue.NewBridge(address, store)
+	case "v2":
+		return hue.NewBridgeV2(address, store)
+	}
+
+	return nil, fmt.Errorf("unknown bridge api '%s'", api)
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/dj95/huekit/pkg/homekit"
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// healthcheckTimeout Maximum duration of the health check
+const healthcheckTimeout = 5 * time.Second
+
+// defaultHealthAddress Listen address of the health checks, that is only
+// reachable from the same host or container
+const defaultHealthAddress = "127.0.0.1:8082"
+
+// lightInfo Light with the decision, if it is bridged, as printed by
+// the lights command
+type lightInfo struct {
+	Bridge       string `json:"bridge,omitempty"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Model        string `json:"model"`
+	Manufacturer string `json:"manufacturer"`
+	On           bool   `json:"on"`
+	Brightness   int    `json:"brightness"`
+	Reachable    bool   `json:"reachable"`
+	Bridged      bool   `json:"bridged"`
+	Reason       string `json:"reason"`
+}
+
+// usage Print the subcommands and flags
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: huekit [flags] [command]
+
+Commands:
+  (none)                   publish the lights in homekit
+  pair                     authenticate at the hue bridges with the link button
+  lights                   list all lights and whether they are bridged
+  set <light> <changes>    change a light by id or name, e.g. on, off, bri=127, ct=366
+  reset                    remove the pairing with the bridges and homekit
+  healthcheck              check the health of the running huekit via the health_address
+  simulate                 run a simulated hue bridge
+
+Flags:
+`)
+	pflag.PrintDefaults()
+}
+
+// pair Authenticate at all bridges, that have no saved username
+func pair() {
+	db, store := openStore()
+	defer db.Close()
+
+	// connecting authenticates at bridges without username
+	bridges, err := connectBridges(store)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	fmt.Printf("paired with %d bridge(s)\n", len(bridges))
+}
+
+// listLights Print all lights of all bridges with the decision, if they
+// are bridged, as table or json
+func listLights() {
+	db, store := openStore()
+	defer db.Close()
+
+	bridges, err := connectBridges(store)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	filter, err := loadFilter()
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	// lights, that were excluded via the api
+	exclusions := homekit.NewExclusions(store)
+
+	var infos []*lightInfo
+
+	for _, bridge := range bridges {
+		lights, err := bridge.Bridge.Lights()
+
+		// error handling
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		for _, light := range lights {
+			bridged, reason := false, "excluded via the api"
+
+			if !exclusions.Excluded(bridge.Key, light.ID) {
+				bridged, reason = filter.Bridged(bridge.Key, light)
+			}
+
+			info := &lightInfo{
+				Bridge:       bridge.Key,
+				ID:           light.ID,
+				Name:         light.Name,
+				Type:         light.Type,
+				Model:        light.ModelID,
+				Manufacturer: light.ManufacturerName,
+				Bridged:      bridged,
+				Reason:       reason,
+			}
+
+			if light.State != nil {
+				info.On = light.State.On
+				info.Brightness = light.State.Brightness
+				info.Reachable = light.State.Reachable
+			}
+
+			infos = append(infos, info)
+		}
+	}
+
+	// print the lights for scripts
+	if viper.GetBool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(infos); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "BRIDGE\tID\tNAME\tTYPE\tMODEL\tMANUFACTURER\tON\tBRIDGED\tREASON")
+
+	for _, info := range infos {
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%t\t%t\t%s\n",
+			info.Bridge, info.ID, info.Name, info.Type, info.Model, info.Manufacturer, info.On, info.Bridged, info.Reason,
+		)
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// setLight Change the state of the light with the given id or name
+func setLight(args []string) {
+	if len(args) < 2 {
+		pflag.Usage()
+		os.Exit(2)
+	}
+
+	state, err := parseState(args[1:])
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	db, store := openStore()
+	defer db.Close()
+
+	bridges, err := connectBridges(store)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	changed := 0
+
+	for _, bridge := range bridges {
+		lights, err := bridge.Bridge.Lights()
+
+		// error handling
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		for _, light := range lights {
+			// select the light by its id or name
+			if light.ID != args[0] && light.Name != args[0] {
+				continue
+			}
+
+			if err := bridge.Bridge.LightUpdateState(light, state); err != nil {
+				log.Fatalf("cannot change light %s: %s", light.ID, err.Error())
+			}
+
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		log.Fatalf("no light with the id or name '%s' found", args[0])
+	}
+
+	fmt.Printf("changed %d light(s)\n", changed)
+}
+
+// parseState Create the state from the changes of the set command. Like
+// in homekit, changing the brightness or color turns the light on.
+func parseState(changes []string) (*hue.State, error) {
+	state := &hue.State{On: true}
+
+	for _, change := range changes {
+		switch change {
+		case "on":
+			state.On = true
+
+			continue
+		case "off":
+			state.On = false
+
+			continue
+		}
+
+		key, value, found := strings.Cut(change, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid change '%s'", change)
+		}
+
+		number, err := strconv.Atoi(value)
+
+		// error handling
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of '%s': %w", key, err)
+		}
+
+		switch key {
+		case "bri":
+			state.Brightness = number
+		case "ct":
+			state.ColorTemperature = number
+		case "hue":
+			state.Hue = hue.Int(number)
+		case "sat":
+			state.Saturation = hue.Int(number)
+		default:
+			return nil, fmt.Errorf("unknown property '%s'", key)
+		}
+	}
+
+	return state, nil
+}
+
+// reset Remove the saved usernames, accessory ids and homekit pairings
+func reset() {
+	// ask for confirmation, as all homekit rooms and automations are
+	// lost afterwards
+	if !viper.GetBool("yes") {
+		fmt.Print("This removes the pairing with the hue bridges and homekit. Continue? [y/N] ")
+
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return
+		}
+	}
+
+	for _, directory := range []string{dataDirectory, homekit.BridgeName} {
+		if err := os.RemoveAll(directory); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Println("huekit was reset")
+}
+
+// healthcheck Query the health endpoint of the running huekit and exit
+// with an error, if it is unhealthy. The docker image calls it, as it
+// contains no curl.
+func healthcheck() {
+	address := viper.GetString("health_address")
+
+	// huekit cannot be checked, if the health checks are disabled
+	if address == "" {
+		log.Fatal("the health_address is not set, cannot check the health")
+	}
+
+	path := "/healthz"
+
+	if viper.GetBool("ready") {
+		path = "/readyz"
+	}
+
+	client := &http.Client{Timeout: healthcheckTimeout}
+
+	res, err := client.Get("http://" + localAddress(address) + path)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if res.StatusCode != http.StatusOK {
+		log.Fatalf("huekit is unhealthy: %s", strings.TrimSpace(string(body)))
+	}
+
+	fmt.Println("huekit is healthy")
+}
+
+// localAddress Return the address, that reaches the api from the same
+// host, if the api listens on all interfaces
+func localAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+
+	// leave invalid addresses to the http client
+	if err != nil {
+		return address
+	}
+
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+
+	return net.JoinHostPort(host, port)
+}
@@ -10,8 +10,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/dj95/huekit/pkg/api"
 	"github.com/dj95/huekit/pkg/homekit"
-	"github.com/dj95/huekit/pkg/hue"
 	"github.com/dj95/huekit/pkg/store"
 )
 
@@ -31,6 +31,17 @@ func init() {
 		viper.SetConfigFile(viper.GetString("config"))
 	}
 
+	// set the default values
+	viper.SetDefault("bridge_api", "v1")
+	viper.SetDefault("sync_interval", "5s")
+	viper.SetDefault("cache_ttl", "1s")
+	viper.SetDefault("reload_interval", "5m")
+	viper.SetDefault("unreachable_timeout", "5m")
+	viper.SetDefault("health_address", defaultHealthAddress)
+	viper.SetDefault("sensors", true)
+	viper.SetDefault("button_poll_interval", "500ms")
+	viper.SetDefault("double_press_window", "800ms")
+
 	// set the env prefix to HUEKIT_ for configuration via
 	// environment variables
 	viper.SetEnvPrefix("HUEKIT")
@@ -78,73 +89,185 @@ func init() {
 	log.SetOutput(logOutput)
 }
 
+// dataDirectory Directory of the database, that contains the usernames
+// and accessory ids
+const dataDirectory = "./huekit_data"
+
 func main() {
-	if viper.GetString("bridge_address") == "" || viper.GetString("homekit_pin") == "" {
-		log.Fatal("Invalid configuration! Either 'bridge_address' or 'homekit_pin' are missing!")
+	// the subcommands print their results on stdout, such that the
+	// logs are written to stderr
+	if command := pflag.Arg(0); command != "" && command != "simulate" {
+		log.SetOutput(os.Stderr)
 	}
 
-	// open the database
-	db, err := badger.Open(
-		badger.
-			DefaultOptions("./huekit_data").
-			WithLogger(log.StandardLogger()).
-			WithValueLogLoadingMode(options.FileIO),
-	)
+	switch pflag.Arg(0) {
+	case "":
+		run()
+	case "simulate":
+		// run a simulated bridge instead of huekit
+		simulate()
+	case "pair":
+		pair()
+	case "lights":
+		listLights()
+	case "set":
+		setLight(pflag.Args()[1:])
+	case "reset":
+		reset()
+	case "healthcheck":
+		healthcheck()
+	default:
+		pflag.Usage()
+		os.Exit(2)
+	}
+}
 
-	// error handling
-	if err != nil {
-		log.Fatal(err)
+// run Publish the lights of the hue bridges in homekit
+func run() {
+	// the bridge address is optional, as the bridge can be discovered
+	if viper.GetString("homekit_pin") == "" {
+		log.Fatal("Invalid configuration! 'homekit_pin' is missing!")
 	}
 
+	// open the database
+	db, store := openStore()
+
 	// close the database on exit
 	defer db.Close()
 
-	// create a new storage with the database as backend
-	store := store.NewBadger(db)
+	// serve the management api and the dashboard, if configured. It
+	// is started before connecting to the bridges, such that the
+	// dashboard shows the bridges, that wait for the link button.
+	handler := api.NewHandler(viper.GetString("api_token"), viper.GetString("homekit_pin"))
 
-	// create a new bridge connection and authenticate,
-	// if no authentication is saved in the storage
-	bridge, err := hue.NewBridge(
-		viper.GetString("bridge_address"),
-		store,
-	)
+	if address := viper.GetString("api_address"); address != "" {
+		go func() {
+			log.Fatal(api.ListenAndServe(address, handler))
+		}()
+	}
+
+	// serve the health checks on their own, such that they do not
+	// depend on the api and its token
+	if address := viper.GetString("health_address"); address != "" {
+		go func() {
+			log.Fatal(api.ListenAndServeHealth(address, handler))
+		}()
+	}
+
+	// connect to all hue bridges and authenticate, if no
+	// authentication is saved in the storage
+	bridges, err := connectBridges(store)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	// create the filter for the published lights
+	filter, err := loadFilter()
 
 	// error handling
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	// fetch all lights
-	lights, err := bridge.Lights()
+	// read the services, that override the default service of
+	// single lights
+	services, err := homekit.NewServiceOverrides(viper.GetStringMapString("services"))
 
 	// error handling
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	// iterate through all the lights
-	for _, light := range lights {
-		log.WithFields(log.Fields{
-			"id":               light.ID,
-			"name":             light.Name,
-			"type":             light.Type,
-			"model":            light.ModelID,
-			"software_version": light.SoftwareVersion,
-		}).Debug("found device")
+	// read the default fades of the changes from homekit
+	fades, err := homekit.NewFades(viper.GetString("transition_time"), viper.GetStringMapString("transition_times"))
+
+	// error handling
+	if err != nil {
+		log.Fatal(err.Error())
 	}
 
-	homekit.StartBridge(
-		viper.GetString("homekit_pin"),
-		viper.GetString("homekit_port"),
-		lights,
-		bridge,
+	// create the homekit bridge with the accessories of all hue
+	// bridges
+	server := homekit.NewServer(
+		homekit.Config{
+			Filter:             filter,
+			Services:           services,
+			Fades:              fades,
+			Pin:                viper.GetString("homekit_pin"),
+			Port:               viper.GetString("homekit_port"),
+			Groups:             viper.GetStringSlice("groups"),
+			Scenes:             viper.GetStringSlice("scenes"),
+			Sensors:            viper.GetBool("sensors"),
+			ButtonPollInterval: viper.GetDuration("button_poll_interval"),
+			DoublePressWindow:  viper.GetDuration("double_press_window"),
+			SyncInterval:       viper.GetDuration("sync_interval"),
+			ReloadInterval:     viper.GetDuration("reload_interval"),
+			UnreachableTimeout: viper.GetDuration("unreachable_timeout"),
+		},
+		bridges,
+		store,
 	)
+
+	// the dashboard shows the lights, once all bridges are connected
+	handler.SetBackend(server)
+
+	homekit.StartBridge(server)
+}
+
+// openStore Open the database and create a storage with it as backend.
+// The database must be closed by the caller.
+func openStore() (*badger.DB, store.Store) {
+	// open the database
+	db, err := badger.Open(
+		badger.
+			DefaultOptions(dataDirectory).
+			WithLogger(log.StandardLogger()).
+			WithValueLogLoadingMode(options.FileIO),
+	)
+
+	// error handling
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return db, store.NewBadger(db)
+}
+
+// loadFilter Create the filter from the include and exclude rules
+func loadFilter() (*homekit.Filter, error) {
+	var include, exclude []homekit.FilterRule
+
+	// read the include rules
+	if err := viper.UnmarshalKey("filter.include", &include); err != nil {
+		return nil, err
+	}
+
+	// read the exclude rules
+	if err := viper.UnmarshalKey("filter.exclude", &exclude); err != nil {
+		return nil, err
+	}
+
+	return homekit.NewFilter(include, exclude)
 }
 
 func initializeCommandFlags() {
-	// create a new flag for docker health checks
+	// create a new flag for the config file
 	pflag.String("config", "", "choose the config file")
 
+	// create the flags for the simulated bridge
+	pflag.String("simulate-address", "127.0.0.1:8080", "listen address of the simulated bridge")
+	pflag.String("simulate-inventory", "", "json file with the devices of the simulated bridge")
+
+	// create the flags for the subcommands
+	pflag.Bool("json", false, "print the lights as json")
+	pflag.Bool("yes", false, "reset without asking for confirmation")
+	pflag.Bool("ready", false, "check the readiness instead of the liveness in the healthcheck")
+
+	// list the subcommands in the help
+	pflag.Usage = usage
+
 	// parse the pflags
 	pflag.Parse()
 
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/dj95/huekit/pkg/hue/huetest"
+)
+
+// simulate Run a simulated hue bridge for demos and local development
+func simulate() {
+	// use the demo household by default
+	inventory := huetest.DemoInventory()
+
+	// read the devices from a file, if configured. The file has the
+	// same format as the full state of a bridge from /api/<username>
+	if path := viper.GetString("simulate-inventory"); path != "" {
+		inventoryBytes, err := os.ReadFile(path) // #nosec G304 the path is given by the user
+
+		// error handling
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		inventory = &huetest.Inventory{}
+
+		// decode the inventory
+		if err := json.Unmarshal(inventoryBytes, inventory); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// create the simulated bridge
+	bridge := huetest.New(inventory)
+
+	// press the link button, such that huekit can authenticate
+	// directly after the start
+	bridge.PressLinkButton()
+
+	address := viper.GetString("simulate-address")
+
+	log.Infof("simulating a hue bridge on %s", address)
+	log.Infof("the link button is pressed for %s. Press it again with: curl -k -X POST https://%s/linkbutton", huetest.LinkButtonDuration, address)
+
+	// generate a self-signed certificate, like a real bridge has one
+	certificate, err := huetest.Certificate()
+
+	// error handling
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// serve the api of the simulated bridge
+	server := &http.Server{
+		Addr:              address,
+		Handler:           bridge,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+
+	log.Fatal(server.ListenAndServeTLS("", ""))
+}
@@ -0,0 +1,671 @@
+// Package api Serve a local rest api for inspecting and managing a
+// running huekit
+package api
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/dj95/huekit/pkg/homekit"
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// ErrMissingToken Returned, if the api should be served without a token
+var ErrMissingToken = errors.New("the api requires a token")
+
+// qrCodeSize Width and height of the setup qr code in pixels
+const qrCodeSize = 256
+
+//go:embed web
+var web embed.FS
+
+// Backend The running huekit, that is inspected and managed with the api
+type Backend interface {
+	Lights() []*homekit.LightStatus
+	Bridge(key string) (hue.Bridger, bool)
+	Controllers() ([]*homekit.Controller, error)
+	Reload()
+	Exclude(bridge, id string) error
+	Include(bridge, id string) error
+	Ready() error
+	Healthy() error
+}
+
+// light Light with its decision and state in the responses
+type light struct {
+	Bridge       string     `json:"bridge,omitempty"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Model        string     `json:"model"`
+	Manufacturer string     `json:"manufacturer"`
+	AccessoryID  uint64     `json:"accessory_id,omitempty"`
+	Bridged      bool       `json:"bridged"`
+	Reason       string     `json:"reason"`
+	State        *hue.State `json:"state,omitempty"`
+}
+
+// controller Paired controller in the responses
+type controller struct {
+	ID        string `json:"id"`
+	PublicKey string `json:"public_key"`
+}
+
+// pairing Pairing status of the homekit bridge in the responses
+type pairing struct {
+	Paired      bool          `json:"paired"`
+	Controllers []*controller `json:"controllers"`
+}
+
+// linkButton Authentication, that waits for the link button, in the
+// responses
+type linkButton struct {
+	Address   string `json:"address"`
+	Remaining int    `json:"remaining"`
+}
+
+// startupStatus Startup status of huekit in the responses
+type startupStatus struct {
+	Ready      bool          `json:"ready"`
+	LinkButton []*linkButton `json:"link_button"`
+}
+
+// setup Setup code of the homekit bridge in the responses
+type setup struct {
+	Pin      string `json:"pin"`
+	SetupURI string `json:"setup_uri"`
+}
+
+// health Body of successful health checks
+type health struct {
+	Status string `json:"status"`
+}
+
+// stateRequest Requested change of the state of a light. Only the
+// fields, that are set, are changed.
+type stateRequest struct {
+	On               *bool     `json:"on"`
+	Brightness       *uint8    `json:"bri"`
+	Hue              *uint16   `json:"hue"`
+	Saturation       *uint8    `json:"sat"`
+	XY               []float64 `json:"xy"`
+	ColorTemperature *uint16   `json:"ct"`
+	Alert            *string   `json:"alert"`
+	Effect           *string   `json:"effect"`
+	TransitionTime   *uint16   `json:"transitiontime"`
+}
+
+// errorResponse Body of all error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler Serves the api and the dashboard. As huekit connects to the
+// bridges after the handler was started, the backend is set afterwards.
+// Until then, only the status, the setup code and the dashboard are
+// served.
+type Handler struct {
+	token string
+	pin   string
+	mux   *http.ServeMux
+
+	// mutex Guards the backend
+	mutex   sync.Mutex
+	backend Backend
+}
+
+// NewHandler Create the handler of the api and the dashboard for the
+// homekit bridge with the given pin. Every api request must contain the
+// token as bearer token in the Authorization header.
+func NewHandler(token, pin string) *Handler {
+	h := &Handler{
+		token: token,
+		pin:   pin,
+		mux:   http.NewServeMux(),
+	}
+
+	// the dashboard contains no secrets, so it is served without
+	// token. It asks for the token and uses the api.
+	files, _ := fs.Sub(web, "web")
+	h.mux.Handle("GET /", http.FileServer(http.FS(files)))
+
+	// container orchestrators check the health without token
+	h.mux.HandleFunc("GET /healthz", h.healthz)
+	h.mux.HandleFunc("GET /readyz", h.readyz)
+
+	h.handle("GET /api/v1/status", h.status)
+	h.handle("GET /api/v1/homekit/setup", h.setup)
+	h.handle("GET /api/v1/homekit/setup.png", h.qrCode)
+	h.handle("GET /api/v1/lights", h.withBackend(h.lights))
+	h.handle("GET /api/v1/lights/{id}", h.withBackend(h.light))
+	h.handle("PUT /api/v1/lights/{id}/state", h.withBackend(h.changeState))
+	h.handle("POST /api/v1/lights/{id}/exclusion", h.withBackend(h.exclude))
+	h.handle("DELETE /api/v1/lights/{id}/exclusion", h.withBackend(h.include))
+	h.handle("GET /api/v1/homekit", h.withBackend(h.homekit))
+	h.handle("POST /api/v1/reload", h.withBackend(h.reload))
+
+	// the metrics contain the names of the lights, so prometheus
+	// must send the token as well
+	h.mux.Handle("GET /metrics", authenticate(h.token, promhttp.Handler()))
+
+	return h
+}
+
+// SetBackend Set the running huekit, once it connected to all bridges
+func (h *Handler) SetBackend(backend Backend) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.backend = backend
+}
+
+// ServeHTTP Serve the api and the dashboard
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe Serve the handler on the given address until an error
+// occurs. A handler without token is refused.
+func ListenAndServe(address string, handler *Handler) error {
+	if handler.token == "" {
+		return ErrMissingToken
+	}
+
+	log.Infof("serving the api and the dashboard on %s", address)
+
+	return newServer(address, handler).ListenAndServe()
+}
+
+// ListenAndServeHealth Serve only the health checks of the handler on the
+// given address until an error occurs. They require no token, such that
+// huekit can be checked without enabling the api.
+func ListenAndServeHealth(address string, handler *Handler) error {
+	log.Infof("serving the health checks on %s", address)
+
+	return newServer(address, handler.Health()).ListenAndServe()
+}
+
+// Health Return the handler, that only serves /healthz and /readyz
+func (h *Handler) Health() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", h.healthz)
+	mux.HandleFunc("GET /readyz", h.readyz)
+
+	return mux
+}
+
+// newServer Create the http server for the handler
+func newServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// handle Register the api endpoint, that requires the token
+func (h *Handler) handle(pattern string, handler http.HandlerFunc) {
+	h.mux.Handle(pattern, authenticate(h.token, handler))
+}
+
+// withBackend Refuse the request, until the backend is set, and pass
+// the backend to the handler otherwise
+func (h *Handler) withBackend(handler func(Backend, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backend := h.currentBackend()
+
+		if backend == nil {
+			writeError(w, http.StatusServiceUnavailable, "huekit is connecting to the bridges")
+
+			return
+		}
+
+		handler(backend, w, r)
+	}
+}
+
+// currentBackend Return the backend or nil, if huekit is connecting to
+// the bridges
+func (h *Handler) currentBackend() Backend {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.backend
+}
+
+// authenticate Refuse all requests without the token
+func authenticate(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		// compare in constant time, such that the token cannot be
+		// guessed by the response time
+		if !found || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "invalid token")
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// status Return, if huekit is connected to all bridges, and the bridges,
+// whose link button must be pressed
+func (h *Handler) status(w http.ResponseWriter, _ *http.Request) {
+	h.mutex.Lock()
+	result := &startupStatus{
+		Ready:      h.backend != nil,
+		LinkButton: []*linkButton{},
+	}
+	h.mutex.Unlock()
+
+	for _, pending := range hue.PendingAuthentications() {
+		result.LinkButton = append(result.LinkButton, &linkButton{
+			Address:   pending.Address,
+			Remaining: int(time.Until(pending.Deadline).Seconds()),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// healthz Fail, if a hue bridge is unreachable for too long. huekit is
+// alive, while it connects to the bridges.
+func (h *Handler) healthz(w http.ResponseWriter, _ *http.Request) {
+	if backend := h.currentBackend(); backend != nil {
+		if err := backend.Healthy(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, &health{Status: "ok"})
+}
+
+// readyz Fail, until huekit is authenticated at all bridges and the
+// homekit bridge is published
+func (h *Handler) readyz(w http.ResponseWriter, _ *http.Request) {
+	backend := h.currentBackend()
+
+	if backend == nil {
+		writeError(w, http.StatusServiceUnavailable, "huekit is connecting to the bridges")
+
+		return
+	}
+
+	if err := backend.Ready(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &health{Status: "ok"})
+}
+
+// setup Return the pin and the setup uri of the homekit bridge
+func (h *Handler) setup(w http.ResponseWriter, _ *http.Request) {
+	uri, err := homekit.SetupURI(h.pin)
+
+	// error handling
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &setup{
+		Pin:      h.pin,
+		SetupURI: uri,
+	})
+}
+
+// qrCode Return the setup uri of the homekit bridge as png qr code, that
+// can be scanned with the home app
+func (h *Handler) qrCode(w http.ResponseWriter, _ *http.Request) {
+	uri, err := homekit.SetupURI(h.pin)
+
+	// error handling
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	image, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+
+	// error handling
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+
+	if _, err := w.Write(image); err != nil {
+		log.Errorf("cannot write the response: %s", err.Error())
+	}
+}
+
+// lights Return all lights with their decision and the cached state of
+// the bridged ones
+func (h *Handler) lights(backend Backend, w http.ResponseWriter, _ *http.Request) {
+	result := []*light{}
+
+	for _, status := range backend.Lights() {
+		result = append(result, convert(backend, status))
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// light Return a single light of the bridge in the query
+func (h *Handler) light(backend Backend, w http.ResponseWriter, r *http.Request) {
+	status := find(backend, r)
+
+	// error handling
+	if status == nil {
+		writeError(w, http.StatusNotFound, "light not found")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, convert(backend, status))
+}
+
+// changeState Change the state of a light, e.g. turn it on or off
+func (h *Handler) changeState(backend Backend, w http.ResponseWriter, r *http.Request) {
+	status := find(backend, r)
+
+	// error handling
+	if status == nil {
+		writeError(w, http.StatusNotFound, "light not found")
+
+		return
+	}
+
+	var request stateRequest
+
+	// decode the requested state. Unknown fields are refused, such
+	// that typos do not silently change nothing.
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid state")
+
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	bridge, ok := backend.Bridge(status.Bridge)
+	if !ok {
+		writeError(w, http.StatusNotFound, "bridge not found")
+
+		return
+	}
+
+	state, err := request.state(bridge, status.Light)
+
+	// error handling
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+
+		return
+	}
+
+	if err := bridge.LightUpdateState(status.Light, state); err != nil {
+		// the light cannot apply the state, e.g. a color on a
+		// dimmable light
+		if refused(err) {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		writeError(w, http.StatusBadGateway, err.Error())
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refused Check, if the bridge refused the state, because the light does
+// not support one of its fields. The v1 api returns the error types 6
+// (parameter not available) and 7 (invalid value) in this case.
+func refused(err error) bool {
+	var apiError *hue.APIError
+
+	if errors.As(err, &apiError) {
+		return apiError.Type == 6 || apiError.Type == 7
+	}
+
+	return errors.Is(err, hue.ErrUnsupportedState)
+}
+
+// validate Refuse empty requests and values, that the bridge does not
+// accept
+func (r *stateRequest) validate() error {
+	empty := r.On == nil && r.Brightness == nil && r.Hue == nil && r.Saturation == nil && r.XY == nil &&
+		r.ColorTemperature == nil && r.Alert == nil && r.Effect == nil && r.TransitionTime == nil
+
+	switch {
+	case empty:
+		return errors.New("empty state")
+	case r.Brightness != nil && (*r.Brightness == 0 || *r.Brightness > 254):
+		return errors.New("bri must be between 1 and 254")
+	case r.Saturation != nil && *r.Saturation > 254:
+		return errors.New("sat must be between 0 and 254")
+	case r.ColorTemperature != nil && (*r.ColorTemperature < 153 || *r.ColorTemperature > 500):
+		return errors.New("ct must be between 153 and 500")
+	case r.XY != nil && (len(r.XY) != 2 || r.XY[0] < 0 || r.XY[0] > 1 || r.XY[1] < 0 || r.XY[1] > 1):
+		return errors.New("xy must be two coordinates between 0 and 1")
+	}
+
+	return nil
+}
+
+// state Build the state, that is sent to the bridge, from the set
+// fields. The bridge always receives the power state, so the current one
+// is kept, unless it is requested.
+func (r *stateRequest) state(bridge hue.Bridger, light *hue.Light) (*hue.State, error) {
+	state := &hue.State{XY: r.XY}
+
+	if r.On != nil {
+		state.On = *r.On
+	} else {
+		current, err := bridge.Light(light.ID)
+
+		// error handling
+		if err != nil {
+			return nil, err
+		}
+
+		state.On = current.State != nil && current.State.On
+	}
+
+	if r.Brightness != nil {
+		state.Brightness = int(*r.Brightness)
+	}
+
+	if r.Hue != nil {
+		state.Hue = hue.Int(int(*r.Hue))
+	}
+
+	if r.Saturation != nil {
+		state.Saturation = hue.Int(int(*r.Saturation))
+	}
+
+	if r.ColorTemperature != nil {
+		state.ColorTemperature = int(*r.ColorTemperature)
+	}
+
+	if r.Alert != nil {
+		state.Alert = *r.Alert
+	}
+
+	if r.Effect != nil {
+		state.Effect = *r.Effect
+	}
+
+	if r.TransitionTime != nil {
+		transitionTime := int(*r.TransitionTime)
+		state.TransitionTime = &transitionTime
+	}
+
+	return state, nil
+}
+
+// exclude Exclude a light and unpublish it
+func (h *Handler) exclude(backend Backend, w http.ResponseWriter, r *http.Request) {
+	changeExclusion(w, r, backend, backend.Exclude)
+}
+
+// include Revert the exclusion of a light and publish it again
+func (h *Handler) include(backend Backend, w http.ResponseWriter, r *http.Request) {
+	changeExclusion(w, r, backend, backend.Include)
+}
+
+// changeExclusion Change the exclusion of the light in the request
+func changeExclusion(w http.ResponseWriter, r *http.Request, backend Backend, change func(bridge, id string) error) {
+	status := find(backend, r)
+
+	// error handling
+	if status == nil {
+		writeError(w, http.StatusNotFound, "light not found")
+
+		return
+	}
+
+	if err := change(status.Bridge, status.Light.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// homekit Return the pairing status and the paired controllers
+func (h *Handler) homekit(backend Backend, w http.ResponseWriter, _ *http.Request) {
+	controllers, err := backend.Controllers()
+
+	// error handling
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	result := &pairing{
+		Paired:      len(controllers) > 0,
+		Controllers: []*controller{},
+	}
+
+	for _, c := range controllers {
+		result.Controllers = append(result.Controllers, &controller{
+			ID:        c.ID,
+			PublicKey: c.PublicKey,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// reload Trigger the discovery of new and deleted devices
+func (h *Handler) reload(backend Backend, w http.ResponseWriter, _ *http.Request) {
+	backend.Reload()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// find Return the light with the id in the path of the bridge in the
+// bridge query parameter
+func find(backend Backend, r *http.Request) *homekit.LightStatus {
+	bridge := r.URL.Query().Get("bridge")
+	id := r.PathValue("id")
+
+	for _, status := range backend.Lights() {
+		if status.Bridge == bridge && status.Light.ID == id {
+			return status
+		}
+	}
+
+	return nil
+}
+
+// convert Create the response for the light. Bridged lights contain
+// the state from the cache of the bridge.
+func convert(backend Backend, status *homekit.LightStatus) *light {
+	result := &light{
+		Bridge:       status.Bridge,
+		ID:           status.Light.ID,
+		Name:         status.Light.Name,
+		Type:         status.Light.Type,
+		Model:        status.Light.ModelID,
+		Manufacturer: status.Light.ManufacturerName,
+		AccessoryID:  status.AccessoryID,
+		Bridged:      status.Bridged,
+		Reason:       status.Reason,
+		State:        status.Light.State,
+	}
+
+	// skipped lights keep the state of the discovery
+	if !status.Bridged {
+		return result
+	}
+
+	bridge, ok := backend.Bridge(status.Bridge)
+	if !ok {
+		return result
+	}
+
+	current, err := bridge.Light(status.Light.ID)
+
+	// fall back to the state of the discovery
+	if err != nil {
+		log.Warnf("cannot fetch the state of light %s: %s", status.Light.ID, err.Error())
+
+		return result
+	}
+
+	result.State = current.State
+
+	return result
+}
+
+// writeJSON Write the value as json response with the given status
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Errorf("cannot write the response: %s", err.Error())
+	}
+}
+
+// writeError Write the message as json error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &errorResponse{Error: message})
+}
@@ -0,0 +1,601 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/homekit"
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// testBackend Stand-in for the running huekit
+type testBackend struct {
+	bridge      hue.Bridger
+	lights      []*homekit.LightStatus
+	controllers []*homekit.Controller
+	reloads     int
+	excluded    map[string]bool
+	ready       error
+	healthy     error
+}
+
+func (b *testBackend) Lights() []*homekit.LightStatus {
+	return b.lights
+}
+
+func (b *testBackend) Bridge(key string) (hue.Bridger, bool) {
+	return b.bridge, key == ""
+}
+
+func (b *testBackend) Controllers() ([]*homekit.Controller, error) {
+	if b.controllers == nil {
+		return nil, errors.New("no storage")
+	}
+
+	return b.controllers, nil
+}
+
+func (b *testBackend) Reload() {
+	b.reloads++
+}
+
+func (b *testBackend) Exclude(bridge, id string) error {
+	b.excluded[bridge+"/"+id] = true
+
+	return nil
+}
+
+func (b *testBackend) Include(bridge, id string) error {
+	b.excluded[bridge+"/"+id] = false
+
+	return nil
+}
+
+func TestNewHandler(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Dimmable light", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm", State: &hue.State{On: true, Brightness: 127}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	backend := &testBackend{
+		bridge: bridge,
+		lights: []*homekit.LightStatus{
+			{
+				Light:       &hue.Light{ID: "1", Type: "Dimmable light", Name: "Desk", State: &hue.State{}},
+				AccessoryID: 2,
+				Bridged:     true,
+				Reason:      "manufacturer 'IKEA of Sweden' is not hue",
+			},
+			{
+				Light:   &hue.Light{ID: "2", Type: "Extended color light", Name: "Living room", State: &hue.State{On: true}},
+				Bridged: false,
+				Reason:  "manufacturer 'Signify Netherlands B.V.' is hue",
+			},
+		},
+		controllers: []*homekit.Controller{{ID: "3D3C6D1A-2B0E-4B8A-9F4C-6A8F2C1E5D7B", PublicKey: "abcd"}},
+		excluded:    map[string]bool{},
+	}
+
+	handler := NewHandler("secret", "00102003")
+
+	// the lights are unavailable, until huekit connected to the bridges
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/lights", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, `{"error":"huekit is connecting to the bridges"}`, strings.TrimSpace(recorder.Body.String()))
+
+	handler.SetBackend(backend)
+
+	setupURI, err := homekit.SetupURI("00102003")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description    string
+		method         string
+		path           string
+		body           string
+		token          string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			description:    "dashboard without token",
+			method:         http.MethodGet,
+			path:           "/",
+			token:          "",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+		},
+		{
+			description:    "metrics without token",
+			method:         http.MethodGet,
+			path:           "/metrics",
+			token:          "",
+			expectedStatus: http.StatusUnauthorized,
+			expectedType:   "application/json",
+			expectedBody:   `{"error":"invalid token"}`,
+		},
+		{
+			description:    "metrics",
+			method:         http.MethodGet,
+			path:           "/metrics",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/plain; version=0.0.4; charset=utf-8; escaping=underscores",
+		},
+		{
+			description:    "status",
+			method:         http.MethodGet,
+			path:           "/api/v1/status",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"ready":true,"link_button":[]}`,
+		},
+		{
+			description:    "homekit setup",
+			method:         http.MethodGet,
+			path:           "/api/v1/homekit/setup",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expectedBody:   `{"pin":"00102003","setup_uri":"` + setupURI + `"}`,
+		},
+		{
+			description:    "homekit setup code without token",
+			method:         http.MethodGet,
+			path:           "/api/v1/homekit/setup.png",
+			token:          "",
+			expectedStatus: http.StatusUnauthorized,
+			expectedType:   "application/json",
+			expectedBody:   `{"error":"invalid token"}`,
+		},
+		{
+			description:    "homekit setup code",
+			method:         http.MethodGet,
+			path:           "/api/v1/homekit/setup.png",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedType:   "image/png",
+		},
+		{
+			description:    "missing token",
+			method:         http.MethodGet,
+			path:           "/api/v1/lights",
+			token:          "",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"invalid token"}`,
+		},
+		{
+			description:    "wrong token",
+			method:         http.MethodGet,
+			path:           "/api/v1/lights",
+			token:          "guessed",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"invalid token"}`,
+		},
+		{
+			description:    "all lights",
+			method:         http.MethodGet,
+			path:           "/api/v1/lights",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody: `[` +
+				`{"id":"1","name":"Desk","type":"Dimmable light","model":"","manufacturer":"","accessory_id":2,"bridged":true,"reason":"manufacturer 'IKEA of Sweden' is not hue","state":{"on":true,"bri":127}},` +
+				`{"id":"2","name":"Living room","type":"Extended color light","model":"","manufacturer":"","bridged":false,"reason":"manufacturer 'Signify Netherlands B.V.' is hue","state":{"on":true}}` +
+				`]`,
+		},
+		{
+			description:    "single light",
+			method:         http.MethodGet,
+			path:           "/api/v1/lights/2",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"2","name":"Living room","type":"Extended color light","model":"","manufacturer":"","bridged":false,"reason":"manufacturer 'Signify Netherlands B.V.' is hue","state":{"on":true}}`,
+		},
+		{
+			description:    "light of an unknown bridge",
+			method:         http.MethodGet,
+			path:           "/api/v1/lights/2?bridge=lab",
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"light not found"}`,
+		},
+		{
+			description:    "exclude a light",
+			method:         http.MethodPost,
+			path:           "/api/v1/lights/1/exclusion",
+			token:          "secret",
+			expectedStatus: http.StatusNoContent,
+			expectedBody:   ``,
+		},
+		{
+			description:    "exclude an unknown light",
+			method:         http.MethodPost,
+			path:           "/api/v1/lights/7/exclusion",
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"light not found"}`,
+		},
+		{
+			description:    "homekit pairing",
+			method:         http.MethodGet,
+			path:           "/api/v1/homekit",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"paired":true,"controllers":[{"id":"3D3C6D1A-2B0E-4B8A-9F4C-6A8F2C1E5D7B","public_key":"abcd"}]}`,
+		},
+		{
+			description:    "reload",
+			method:         http.MethodPost,
+			path:           "/api/v1/reload",
+			token:          "secret",
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   ``,
+		},
+		{
+			description:    "turn a light off",
+			method:         http.MethodPut,
+			path:           "/api/v1/lights/1/state",
+			body:           `{"on":false}`,
+			token:          "secret",
+			expectedStatus: http.StatusNoContent,
+			expectedBody:   ``,
+		},
+		{
+			description:    "invalid state",
+			method:         http.MethodPut,
+			path:           "/api/v1/lights/1/state",
+			body:           `{"on":`,
+			token:          "secret",
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   "application/json",
+			expectedBody:   `{"error":"invalid state"}`,
+		},
+		{
+			description:    "state of an unknown light",
+			method:         http.MethodPut,
+			path:           "/api/v1/lights/7/state",
+			body:           `{"on":true}`,
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "application/json",
+			expectedBody:   `{"error":"light not found"}`,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equalf(t, test.expectedStatus, recorder.Code, test.description)
+
+		if test.expectedType != "" {
+			assert.Equalf(t, test.expectedType, recorder.Header().Get("Content-Type"), test.description)
+		}
+
+		// the dashboard and the qr code are not compared byte by byte
+		if test.expectedBody != "" || test.expectedType == "application/json" {
+			assert.Equalf(t, test.expectedBody, strings.TrimSpace(recorder.Body.String()), test.description)
+		}
+	}
+
+	// the state is changed on the bridge
+	light, err := bridge.Light("1")
+	assert.Nil(t, err)
+	assert.False(t, light.State.On)
+
+	assert.Equal(t, map[string]bool{"/1": true}, backend.excluded)
+	assert.Equal(t, 1, backend.reloads)
+}
+
+func TestHandler_ChangeState(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Extended color light", Name: "Desk", ModelID: "TRADFRI bulb E27 CWS opal 600lm", State: &hue.State{On: true, Brightness: 127, Hue: hue.Int(1000), Saturation: hue.Int(200)}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	handler := NewHandler("secret", "00102003")
+	handler.SetBackend(&testBackend{
+		bridge: bridge,
+		lights: []*homekit.LightStatus{
+			{Light: &hue.Light{ID: "1", Type: "Extended color light", Name: "Desk"}, Bridged: true},
+		},
+	})
+
+	tests := []struct {
+		description        string
+		body               string
+		expectedStatus     int
+		expectedBody       string
+		expectedOn         bool
+		expectedBrightness int
+		expectedHue        int
+		expectedSaturation int
+	}{
+		{
+			description:        "brightness keeps the light on",
+			body:               `{"bri":100}`,
+			expectedStatus:     http.StatusNoContent,
+			expectedOn:         true,
+			expectedBrightness: 100,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "empty state",
+			body:               `{}`,
+			expectedStatus:     http.StatusBadRequest,
+			expectedBody:       `{"error":"empty state"}`,
+			expectedOn:         true,
+			expectedBrightness: 100,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "unknown field",
+			body:               `{"brightness":50}`,
+			expectedStatus:     http.StatusBadRequest,
+			expectedBody:       `{"error":"invalid state"}`,
+			expectedOn:         true,
+			expectedBrightness: 100,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "brightness out of range",
+			body:               `{"bri":0}`,
+			expectedStatus:     http.StatusBadRequest,
+			expectedBody:       `{"error":"bri must be between 1 and 254"}`,
+			expectedOn:         true,
+			expectedBrightness: 100,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "turned off",
+			body:               `{"on":false}`,
+			expectedStatus:     http.StatusNoContent,
+			expectedOn:         false,
+			expectedBrightness: 100,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "brightness keeps the light off",
+			body:               `{"bri":50}`,
+			expectedStatus:     http.StatusNoContent,
+			expectedOn:         false,
+			expectedBrightness: 50,
+			expectedHue:        1000,
+			expectedSaturation: 200,
+		},
+		{
+			description:        "red without saturation",
+			body:               `{"hue":0,"sat":0}`,
+			expectedStatus:     http.StatusNoContent,
+			expectedOn:         false,
+			expectedBrightness: 50,
+			expectedHue:        0,
+			expectedSaturation: 0,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPut, "/api/v1/lights/1/state", strings.NewReader(test.body))
+		request.Header.Set("Authorization", "Bearer secret")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equalf(t, test.expectedStatus, recorder.Code, test.description)
+		assert.Equalf(t, test.expectedBody, strings.TrimSpace(recorder.Body.String()), test.description)
+
+		state := simulator.Light("1").State
+
+		assert.Equalf(t, test.expectedOn, state.On, test.description)
+		assert.Equalf(t, test.expectedBrightness, state.Brightness, test.description)
+		assert.Equalf(t, test.expectedHue, *state.Hue, test.description)
+		assert.Equalf(t, test.expectedSaturation, *state.Saturation, test.description)
+	}
+}
+
+func TestHandler_ChangeStateRefused(t *testing.T) {
+	tests := []struct {
+		description    string
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			description:    "unsupported on the v2 api",
+			err:            fmt.Errorf("%w: effect 'colorloop'", hue.ErrUnsupportedState),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"state not supported: effect 'colorloop'"}`,
+		},
+		{
+			description:    "parameter not available on the v1 api",
+			err:            &hue.APIError{Type: 6, Description: "parameter, effect, not available"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"parameter, effect, not available"}`,
+		},
+		{
+			description:    "invalid value on the v1 api",
+			err:            &hue.APIError{Type: 7, Description: "invalid value, blink, for parameter, alert"},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"invalid value, blink, for parameter, alert"}`,
+		},
+		{
+			description:    "unreachable bridge",
+			err:            errors.New("connection refused"),
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"error":"connection refused"}`,
+		},
+	}
+
+	for _, test := range tests {
+		handler := NewHandler("secret", "00102003")
+		handler.SetBackend(&testBackend{
+			bridge: &refusingBridge{err: test.err},
+			lights: []*homekit.LightStatus{
+				{Light: &hue.Light{ID: "1", Type: "Dimmable light", Name: "Desk"}, Bridged: true},
+			},
+		})
+
+		request := httptest.NewRequest(http.MethodPut, "/api/v1/lights/1/state", strings.NewReader(`{"on":true,"effect":"colorloop"}`))
+		request.Header.Set("Authorization", "Bearer secret")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equalf(t, test.expectedStatus, recorder.Code, test.description)
+		assert.Equalf(t, test.expectedBody, strings.TrimSpace(recorder.Body.String()), test.description)
+	}
+}
+
+// refusingBridge Fail every change of a light with the error
+type refusingBridge struct {
+	hue.Bridger
+
+	err error
+}
+
+func (b *refusingBridge) LightUpdateState(*hue.Light, *hue.State) error {
+	return b.err
+}
+
+func (b *testBackend) Ready() error {
+	return b.ready
+}
+
+func (b *testBackend) Healthy() error {
+	return b.healthy
+}
+
+func TestHandler_Health(t *testing.T) {
+	tests := []struct {
+		description    string
+		backend        *testBackend
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			description:    "alive while connecting",
+			backend:        nil,
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok"}`,
+		},
+		{
+			description:    "not ready while connecting",
+			backend:        nil,
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"huekit is connecting to the bridges"}`,
+		},
+		{
+			description:    "not ready until published",
+			backend:        &testBackend{ready: homekit.ErrNotPublished},
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"the homekit bridge is not published yet"}`,
+		},
+		{
+			description:    "ready",
+			backend:        &testBackend{},
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok"}`,
+		},
+		{
+			description:    "unreachable bridge",
+			backend:        &testBackend{healthy: errors.New("the hue bridge is unreachable for 5m12s")},
+			path:           "/healthz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"the hue bridge is unreachable for 5m12s"}`,
+		},
+		{
+			description:    "healthy",
+			backend:        &testBackend{},
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok"}`,
+		},
+	}
+
+	for _, test := range tests {
+		// the health checks require no token
+		handler := NewHandler("secret", "00102003")
+
+		if test.backend != nil {
+			handler.SetBackend(test.backend)
+		}
+
+		// they are served with the api and on their own
+		for _, served := range []http.Handler{handler, handler.Health()} {
+			recorder := httptest.NewRecorder()
+			served.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			assert.Equalf(t, test.expectedStatus, recorder.Code, test.description)
+			assert.Equalf(t, test.expectedBody, strings.TrimSpace(recorder.Body.String()), test.description)
+		}
+	}
+
+	// the api is not served with the health checks
+	recorder := httptest.NewRecorder()
+	NewHandler("", "00102003").Health().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestListenAndServe(t *testing.T) {
+	err := ListenAndServe("127.0.0.1:0", NewHandler("", ""))
+
+	assert.Equal(t, ErrMissingToken, err)
+
+	// the health checks are served without token, so only the
+	// invalid address is refused
+	err = ListenAndServeHealth("127.0.0.1:-1", NewHandler("", ""))
+
+	assert.NotNil(t, err)
+	assert.NotEqual(t, ErrMissingToken, err)
+}
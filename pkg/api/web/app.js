@@ -0,0 +1,168 @@
+"use strict";
+
+// the token is kept in the browser, such that it is only entered once
+const tokenKey = "huekit_token";
+
+// refresh the status and the lights in this interval in milliseconds
+const refreshInterval = 2000;
+
+const $ = (id) => document.getElementById(id);
+
+// request Perform an authenticated request against the api
+async function request(method, path, body) {
+  const response = await fetch(path, {
+    method: method,
+    headers: {
+      "Authorization": "Bearer " + localStorage.getItem(tokenKey),
+      "Content-Type": "application/json",
+    },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+
+  if (response.status === 401) {
+    localStorage.removeItem(tokenKey);
+    showLogin("The token is invalid.");
+
+    throw new Error("invalid token");
+  }
+
+  return response;
+}
+
+// showLogin Ask for the token
+function showLogin(message) {
+  $("login").hidden = false;
+  $("logout").hidden = true;
+  $("link-button").hidden = true;
+  $("setup").hidden = true;
+  $("lights").hidden = true;
+
+  $("login-error").hidden = !message;
+  $("login-error").textContent = message || "";
+}
+
+// showSetup Show the pin and the qr code of the homekit bridge
+async function showSetup() {
+  const setup = await (await request("GET", "/api/v1/homekit/setup")).json();
+
+  $("setup-pin").textContent = setup.pin.replace(/^(\d{3})(\d{2})(\d{3})$/, "$1-$2-$3");
+  $("setup-uri").textContent = setup.setup_uri;
+
+  // the qr code requires the token, so it is fetched as blob
+  const image = await (await request("GET", "/api/v1/homekit/setup.png")).blob();
+  $("setup-code").src = URL.createObjectURL(image);
+
+  $("setup").hidden = false;
+}
+
+// refreshStatus Show the bridges, that wait for the link button
+async function refreshStatus() {
+  const status = await (await request("GET", "/api/v1/status")).json();
+
+  const list = $("link-button-list");
+  list.replaceChildren();
+
+  for (const pending of status.link_button) {
+    const item = document.createElement("li");
+    item.textContent = `${pending.address} (${pending.remaining}s left)`;
+    list.appendChild(item);
+  }
+
+  $("link-button").hidden = status.link_button.length === 0;
+
+  return status.ready;
+}
+
+// refreshLights Show all lights with a toggle for the bridged ones
+async function refreshLights() {
+  const response = await request("GET", "/api/v1/lights");
+  const lights = await response.json();
+
+  const list = $("lights-list");
+  list.replaceChildren();
+
+  for (const light of lights) {
+    const row = document.createElement("tr");
+
+    for (const text of [light.name, light.type, light.manufacturer]) {
+      const cell = document.createElement("td");
+      cell.textContent = text;
+      row.appendChild(cell);
+    }
+
+    const bridged = document.createElement("td");
+    bridged.className = "reason";
+    bridged.textContent = (light.bridged ? "yes: " : "no: ") + light.reason;
+    row.appendChild(bridged);
+
+    const on = document.createElement("td");
+    const toggle = document.createElement("input");
+    toggle.type = "checkbox";
+    toggle.checked = light.state !== undefined && light.state.on;
+    toggle.addEventListener("change", () => setLight(light, toggle.checked));
+    on.appendChild(toggle);
+    row.appendChild(on);
+
+    list.appendChild(row);
+  }
+
+  $("lights-status").textContent = `${lights.filter((light) => light.bridged).length} of ${lights.length} lights are published in homekit.`;
+  $("lights").hidden = false;
+}
+
+// setLight Turn the light on or off
+async function setLight(light, on) {
+  const query = light.bridge ? "?bridge=" + encodeURIComponent(light.bridge) : "";
+
+  await request("PUT", `/api/v1/lights/${encodeURIComponent(light.id)}/state${query}`, { on: on });
+}
+
+// refresh Update the dashboard. The lights are only available, once
+// huekit connected to all bridges.
+async function refresh() {
+  try {
+    if (await refreshStatus()) {
+      await refreshLights();
+    }
+  } catch (error) {
+    console.error(error);
+
+    return;
+  }
+
+  setTimeout(refresh, refreshInterval);
+}
+
+// start Show the dashboard
+async function start() {
+  $("login").hidden = true;
+  $("logout").hidden = false;
+
+  try {
+    await showSetup();
+  } catch (error) {
+    console.error(error);
+
+    return;
+  }
+
+  refresh();
+}
+
+$("login-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+
+  localStorage.setItem(tokenKey, $("token").value);
+  start();
+});
+
+$("logout").addEventListener("click", () => {
+  localStorage.removeItem(tokenKey);
+  showLogin();
+});
+
+if (localStorage.getItem(tokenKey)) {
+  start();
+} else {
+  showLogin();
+}
@@ -0,0 +1,308 @@
+// Package color Converts the colors of homekit into the CIE xy color
+// space of hue lights and back. The conversion uses the wide gamut rgb
+// space with the D65 white point, that is recommended by hue.
+package color
+
+import (
+	"math"
+	"strings"
+)
+
+// Point Chromaticity in the CIE xy color space
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Gamut Triangle in the CIE xy color space with the colors, that a light
+// can show
+type Gamut struct {
+	Red   Point
+	Green Point
+	Blue  Point
+}
+
+var (
+	// GamutA Gamut of the lightstrips and living colors
+	GamutA = &Gamut{
+		Red:   Point{X: 0.704, Y: 0.296},
+		Green: Point{X: 0.2151, Y: 0.7106},
+		Blue:  Point{X: 0.138, Y: 0.08},
+	}
+
+	// GamutB Gamut of the first hue color lamps
+	GamutB = &Gamut{
+		Red:   Point{X: 0.675, Y: 0.322},
+		Green: Point{X: 0.409, Y: 0.518},
+		Blue:  Point{X: 0.167, Y: 0.04},
+	}
+
+	// GamutC Gamut of the current hue color lamps
+	GamutC = &Gamut{
+		Red:   Point{X: 0.6915, Y: 0.3083},
+		Green: Point{X: 0.17, Y: 0.7},
+		Blue:  Point{X: 0.1532, Y: 0.0475},
+	}
+
+	// GamutDefault Gamut of lights, that report no gamut. It contains
+	// all colors, such that they are sent unchanged.
+	GamutDefault = &Gamut{
+		Red:   Point{X: 1, Y: 0},
+		Green: Point{X: 0, Y: 1},
+		Blue:  Point{X: 0, Y: 0},
+	}
+)
+
+// NewGamut Return the gamut of a light with the given gamut type and
+// points from its capabilities. The points are preferred, as they are
+// reported by the light itself.
+func NewGamut(gamutType string, points [][]float64) *Gamut {
+	// the points are ordered red, green and blue
+	if len(points) == 3 && len(points[0]) == 2 && len(points[1]) == 2 && len(points[2]) == 2 {
+		return &Gamut{
+			Red:   Point{X: points[0][0], Y: points[0][1]},
+			Green: Point{X: points[1][0], Y: points[1][1]},
+			Blue:  Point{X: points[2][0], Y: points[2][1]},
+		}
+	}
+
+	switch strings.ToUpper(gamutType) {
+	case "A":
+		return GamutA
+	case "B":
+		return GamutB
+	case "C":
+		return GamutC
+	default:
+		return GamutDefault
+	}
+}
+
+// Contains Return, if the light can show the color
+func (g *Gamut) Contains(p Point) bool {
+	// the point is inside, if it is on the same side of all edges
+	a := cross(g.Red, g.Green, p)
+	b := cross(g.Green, g.Blue, p)
+	c := cross(g.Blue, g.Red, p)
+
+	return (a >= 0 && b >= 0 && c >= 0) || (a <= 0 && b <= 0 && c <= 0)
+}
+
+// Clamp Return the color, if the light can show it, and the closest
+// color on the edge of the gamut otherwise
+func (g *Gamut) Clamp(p Point) Point {
+	if g.Contains(p) {
+		return p
+	}
+
+	result := closest(g.Red, g.Green, p)
+
+	for _, candidate := range []Point{closest(g.Green, g.Blue, p), closest(g.Blue, g.Red, p)} {
+		if distance(candidate, p) < distance(result, p) {
+			result = candidate
+		}
+	}
+
+	return result
+}
+
+// FromHSV Convert the homekit hue (0 - 360 [°]) and saturation
+// (0 - 100 [%]) into a color, that the light can show. The brightness is
+// set separately with the bri of the light.
+func FromHSV(hue, saturation float64, gamut *Gamut) Point {
+	red, green, blue := hsvToRGB(hue, saturation/100)
+
+	// remove the gamma correction of srgb
+	red, green, blue = linearize(red), linearize(green), linearize(blue)
+
+	// convert into the CIE XYZ color space
+	x := red*0.664511 + green*0.154324 + blue*0.162028
+	y := red*0.283881 + green*0.668433 + blue*0.047685
+	z := red*0.000088 + green*0.072310 + blue*0.986039
+
+	// black has no chromaticity, so white is used instead
+	if x+y+z == 0 {
+		return gamut.Clamp(whitePoint)
+	}
+
+	return gamut.Clamp(Point{
+		X: x / (x + y + z),
+		Y: y / (x + y + z),
+	})
+}
+
+// ToHSV Convert the color of a light into the homekit hue (0 - 360 [°])
+// and saturation (0 - 100 [%])
+func ToHSV(p Point, gamut *Gamut) (float64, float64) {
+	p = gamut.Clamp(p)
+
+	// the color has no chromaticity without luminance
+	if p.Y == 0 {
+		return 0, 0
+	}
+
+	// convert into the CIE XYZ color space with full luminance
+	x := p.X / p.Y
+	y := 1.0
+	z := (1 - p.X - p.Y) / p.Y
+
+	red := x*1.656492 - y*0.354851 - z*0.255038
+	green := -x*0.707196 + y*1.655397 + z*0.036152
+	blue := x*0.051713 - y*0.121364 + z*1.011530
+
+	// colors outside of srgb are moved onto its edge
+	minimum := math.Min(red, math.Min(green, blue))
+
+	if minimum < 0 {
+		red, green, blue = red-minimum, green-minimum, blue-minimum
+	}
+
+	// scale the brightest channel to the maximum, as the brightness
+	// is set separately
+	maximum := math.Max(red, math.Max(green, blue))
+
+	if maximum == 0 {
+		return 0, 0
+	}
+
+	red, green, blue = compand(red/maximum), compand(green/maximum), compand(blue/maximum)
+
+	hue, saturation := rgbToHS(red, green, blue)
+
+	return hue, saturation * 100
+}
+
+// FromColorTemperature Return the color of white light with the given
+// color temperature in mired on the planckian locus
+func FromColorTemperature(mired int) Point {
+	if mired <= 0 {
+		return whitePoint
+	}
+
+	// the approximation is valid between 1667 and 25000 kelvin
+	kelvin := math.Min(25000, math.Max(1667, 1000000/float64(mired)))
+
+	var x float64
+
+	if kelvin <= 4000 {
+		x = -0.2661239e9/math.Pow(kelvin, 3) - 0.2343589e6/math.Pow(kelvin, 2) + 0.8776956e3/kelvin + 0.179910
+	} else {
+		x = -3.0258469e9/math.Pow(kelvin, 3) + 2.1070379e6/math.Pow(kelvin, 2) + 0.2226347e3/kelvin + 0.240390
+	}
+
+	var y float64
+
+	switch {
+	case kelvin <= 2222:
+		y = -1.1063814*math.Pow(x, 3) - 1.34811020*math.Pow(x, 2) + 2.18555832*x - 0.20219683
+	case kelvin <= 4000:
+		y = -0.9549476*math.Pow(x, 3) - 1.37418593*math.Pow(x, 2) + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*math.Pow(x, 3) - 5.87338670*math.Pow(x, 2) + 3.75112997*x - 0.37001483
+	}
+
+	return Point{X: x, Y: y}
+}
+
+// whitePoint Chromaticity of the D65 white point
+var whitePoint = Point{X: 0.3127, Y: 0.329}
+
+// hsvToRGB Convert the hue (0 - 360 [°]) and saturation (0 - 1) with
+// full value into rgb (0 - 1)
+func hsvToRGB(hue, saturation float64) (float64, float64, float64) {
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 60
+	saturation = math.Min(1, math.Max(0, saturation))
+
+	chroma := saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue, 2)-1))
+	m := 1 - chroma
+
+	var red, green, blue float64
+
+	switch int(hue) {
+	case 0:
+		red, green, blue = chroma, x, 0
+	case 1:
+		red, green, blue = x, chroma, 0
+	case 2:
+		red, green, blue = 0, chroma, x
+	case 3:
+		red, green, blue = 0, x, chroma
+	case 4:
+		red, green, blue = x, 0, chroma
+	default:
+		red, green, blue = chroma, 0, x
+	}
+
+	return red + m, green + m, blue + m
+}
+
+// rgbToHS Convert rgb (0 - 1) into the hue (0 - 360 [°]) and the
+// saturation (0 - 1)
+func rgbToHS(red, green, blue float64) (float64, float64) {
+	maximum := math.Max(red, math.Max(green, blue))
+	minimum := math.Min(red, math.Min(green, blue))
+	delta := maximum - minimum
+
+	if maximum == 0 || delta == 0 {
+		return 0, 0
+	}
+
+	var hue float64
+
+	switch maximum {
+	case red:
+		hue = math.Mod((green-blue)/delta, 6)
+	case green:
+		hue = (blue-red)/delta + 2
+	default:
+		hue = (red-green)/delta + 4
+	}
+
+	hue *= 60
+
+	if hue < 0 {
+		hue += 360
+	}
+
+	return hue, delta / maximum
+}
+
+// linearize Remove the gamma correction of an srgb channel
+func linearize(value float64) float64 {
+	if value > 0.04045 {
+		return math.Pow((value+0.055)/1.055, 2.4)
+	}
+
+	return value / 12.92
+}
+
+// compand Apply the gamma correction of srgb to a linear channel
+func compand(value float64) float64 {
+	if value <= 0.0031308 {
+		return 12.92 * value
+	}
+
+	return 1.055*math.Pow(value, 1/2.4) - 0.055
+}
+
+// cross Return the cross product of the edge from a to b and the vector
+// from a to p. Its sign tells the side of the edge, that p is on.
+func cross(a, b, p Point) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// closest Return the point on the edge from a to b, that is closest to p
+func closest(a, b, p Point) Point {
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+	t = math.Min(1, math.Max(0, t))
+
+	return Point{X: a.X + t*dx, Y: a.Y + t*dy}
+}
+
+// distance Return the distance between two points
+func distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
@@ -0,0 +1,208 @@
+package color
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGamut(t *testing.T) {
+	tests := []struct {
+		description    string
+		gamutType      string
+		points         [][]float64
+		expectedResult *Gamut
+	}{
+		{
+			description:    "gamut a",
+			gamutType:      "A",
+			expectedResult: GamutA,
+		},
+		{
+			description:    "gamut b",
+			gamutType:      "B",
+			expectedResult: GamutB,
+		},
+		{
+			description:    "gamut c of the v2 api",
+			gamutType:      "c",
+			expectedResult: GamutC,
+		},
+		{
+			description: "points from the capabilities",
+			gamutType:   "C",
+			points:      [][]float64{{0.6915, 0.3083}, {0.17, 0.7}, {0.1532, 0.0475}},
+			expectedResult: &Gamut{
+				Red:   Point{X: 0.6915, Y: 0.3083},
+				Green: Point{X: 0.17, Y: 0.7},
+				Blue:  Point{X: 0.1532, Y: 0.0475},
+			},
+		},
+		{
+			description:    "invalid points",
+			gamutType:      "B",
+			points:         [][]float64{{0.675, 0.322}, {0.409}},
+			expectedResult: GamutB,
+		},
+		{
+			description:    "third party light",
+			gamutType:      "other",
+			expectedResult: GamutDefault,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.expectedResult, NewGamut(test.gamutType, test.points), test.description)
+	}
+}
+
+func TestGamut_Clamp(t *testing.T) {
+	tests := []struct {
+		description    string
+		gamut          *Gamut
+		point          Point
+		expectedResult Point
+	}{
+		{
+			description:    "inside",
+			gamut:          GamutC,
+			point:          Point{X: 0.4573, Y: 0.41},
+			expectedResult: Point{X: 0.4573, Y: 0.41},
+		},
+		{
+			description:    "beyond the red corner",
+			gamut:          GamutC,
+			point:          Point{X: 0.75, Y: 0.25},
+			expectedResult: Point{X: 0.6915, Y: 0.3083},
+		},
+		{
+			description:    "beyond the edge between green and blue",
+			gamut:          GamutA,
+			point:          Point{X: 0.1, Y: 0.4},
+			expectedResult: Point{X: 0.176, Y: 0.3907},
+		},
+		{
+			description:    "everything is inside the default gamut",
+			gamut:          GamutDefault,
+			point:          Point{X: 0.1724, Y: 0.7468},
+			expectedResult: Point{X: 0.1724, Y: 0.7468},
+		},
+	}
+
+	for _, test := range tests {
+		result := test.gamut.Clamp(test.point)
+
+		assert.InDeltaf(t, test.expectedResult.X, result.X, 0.0001, test.description)
+		assert.InDeltaf(t, test.expectedResult.Y, result.Y, 0.0001, test.description)
+		assert.Truef(t, test.gamut.Contains(result), test.description)
+	}
+}
+
+func TestHSV(t *testing.T) {
+	tests := []struct {
+		description        string
+		hue                float64
+		saturation         float64
+		gamut              *Gamut
+		expectedPoint      Point
+		expectedHue        float64
+		expectedSaturation float64
+	}{
+		{
+			description:        "red",
+			hue:                0,
+			saturation:         100,
+			gamut:              GamutDefault,
+			expectedPoint:      Point{X: 0.7006, Y: 0.2993},
+			expectedHue:        360,
+			expectedSaturation: 100,
+		},
+		{
+			description:        "red of a gamut c light",
+			hue:                0,
+			saturation:         100,
+			gamut:              GamutC,
+			expectedPoint:      Point{X: 0.6915, Y: 0.3083},
+			expectedHue:        9.6,
+			expectedSaturation: 100,
+		},
+		{
+			description:        "green of a gamut b light",
+			hue:                120,
+			saturation:         100,
+			gamut:              GamutB,
+			expectedPoint:      Point{X: 0.409, Y: 0.518},
+			expectedHue:        66.3,
+			expectedSaturation: 73.7,
+		},
+		{
+			description:        "pastel blue",
+			hue:                200,
+			saturation:         20,
+			gamut:              GamutC,
+			expectedPoint:      Point{X: 0.2744, Y: 0.312},
+			expectedHue:        200,
+			expectedSaturation: 20,
+		},
+		{
+			description:        "white",
+			hue:                0,
+			saturation:         0,
+			gamut:              GamutC,
+			expectedPoint:      Point{X: 0.3227, Y: 0.329},
+			expectedSaturation: 0,
+		},
+	}
+
+	for _, test := range tests {
+		point := FromHSV(test.hue, test.saturation, test.gamut)
+
+		assert.InDeltaf(t, test.expectedPoint.X, point.X, 0.0001, test.description)
+		assert.InDeltaf(t, test.expectedPoint.Y, point.Y, 0.0001, test.description)
+
+		hue, saturation := ToHSV(point, test.gamut)
+
+		// the hue is meaningless without saturation
+		if test.expectedSaturation > 0 {
+			assert.InDeltaf(t, test.expectedHue, hue, 0.1, test.description)
+		}
+
+		assert.InDeltaf(t, test.expectedSaturation, saturation, 0.1, test.description)
+	}
+}
+
+func TestFromColorTemperature(t *testing.T) {
+	tests := []struct {
+		description    string
+		mired          int
+		expectedResult Point
+	}{
+		{
+			description:    "cold white",
+			mired:          153,
+			expectedResult: Point{X: 0.3129, Y: 0.3231},
+		},
+		{
+			description:    "warm white",
+			mired:          366,
+			expectedResult: Point{X: 0.4567, Y: 0.4101},
+		},
+		{
+			description:    "candle light",
+			mired:          500,
+			expectedResult: Point{X: 0.5269, Y: 0.4133},
+		},
+		{
+			description:    "unknown color temperature",
+			mired:          0,
+			expectedResult: Point{X: 0.3127, Y: 0.329},
+		},
+	}
+
+	for _, test := range tests {
+		result := FromColorTemperature(test.mired)
+
+		assert.InDeltaf(t, test.expectedResult.X, result.X, 0.0001, test.description)
+		assert.InDeltaf(t, test.expectedResult.Y, result.Y, 0.0001, test.description)
+	}
+}
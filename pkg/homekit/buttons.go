@@ -0,0 +1,201 @@
+package homekit
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brutella/hc/characteristic"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// ButtonMonitor Polls the switches at the bridge and emits homekit
+// events for new button events
+type ButtonMonitor struct {
+	bridge            hue.Bridger
+	interval          time.Duration
+	doublePressWindow time.Duration
+
+	mutex    sync.Mutex
+	switches map[string]*ProgrammableSwitch
+	types    map[string]string
+	states   map[string]*hue.SensorState
+	pending  map[string]*time.Timer
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewButtonMonitor Create a new monitor, that polls the switches in the
+// given interval. Two short presses of a button within the double press
+// window are emitted as double press, when the switch cannot report
+// double presses on its own. A window of zero disables the detection.
+func NewButtonMonitor(bridge hue.Bridger, interval, doublePressWindow time.Duration) *ButtonMonitor {
+	return &ButtonMonitor{
+		bridge:            bridge,
+		interval:          interval,
+		doublePressWindow: doublePressWindow,
+		switches:          map[string]*ProgrammableSwitch{},
+		types:             map[string]string{},
+		states:            map[string]*hue.SensorState{},
+		pending:           map[string]*time.Timer{},
+	}
+}
+
+// Register Add an accessory, that should receive the button events of
+// the switch
+func (m *ButtonMonitor) Register(sensor *hue.Sensor, sw *ProgrammableSwitch) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.switches[sensor.ID] = sw
+	m.types[sensor.ID] = sensor.Type
+	m.states[sensor.ID] = sensor.State
+}
+
+// Poll Fetch all sensors once and emit the new button events of the
+// registered switches
+func (m *ButtonMonitor) Poll() error {
+	// fetch all sensors with a single request
+	sensors, err := m.bridge.Sensors()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// iterate through all sensors
+	for _, sensor := range sensors {
+		// skip sensors without state or accessory
+		sw, ok := m.switches[sensor.ID]
+		if !ok || sensor.State == nil {
+			continue
+		}
+
+		// the last state is required in order to detect new events
+		last := m.states[sensor.ID]
+		m.states[sensor.ID] = sensor.State
+
+		// skip the switch, if no button was pressed since the
+		// last poll
+		if last == nil ||
+			(last.LastUpdated == sensor.State.LastUpdated && last.ButtonEvent == sensor.State.ButtonEvent) {
+			continue
+		}
+
+		// decode and emit the event
+		button, action := hue.DecodeButtonEvent(m.types[sensor.ID], sensor.State.ButtonEvent)
+
+		log.WithFields(log.Fields{
+			"id":     sensor.ID,
+			"name":   sensor.Name,
+			"button": button,
+			"event":  sensor.State.ButtonEvent,
+		}).Debug("button event")
+
+		m.emit(sensor.ID, sw, button, action)
+	}
+
+	return nil
+}
+
+// emit Convert the action of the button into a homekit event. The mutex
+// must be held by the caller.
+func (m *ButtonMonitor) emit(id string, sw *ProgrammableSwitch, button int, action hue.ButtonAction) {
+	switch action {
+	case hue.ButtonActionLongRelease:
+		sw.Press(button, characteristic.ProgrammableSwitchEventLongPress)
+	case hue.ButtonActionDoublePress:
+		sw.Press(button, characteristic.ProgrammableSwitchEventDoublePress)
+	case hue.ButtonActionShortRelease:
+		// emit the single press directly, when the double press
+		// detection is disabled
+		if m.doublePressWindow <= 0 {
+			sw.Press(button, characteristic.ProgrammableSwitchEventSinglePress)
+
+			return
+		}
+
+		key := id + "/" + strconv.Itoa(button)
+
+		// a second short press within the window is a double press
+		if timer, ok := m.pending[key]; ok {
+			timer.Stop()
+			delete(m.pending, key)
+
+			sw.Press(button, characteristic.ProgrammableSwitchEventDoublePress)
+
+			return
+		}
+
+		// otherwise wait for a second press, before the single press
+		// is emitted
+		var timer *time.Timer
+
+		timer = time.AfterFunc(m.doublePressWindow, func() {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+
+			// the press may already be emitted as double press
+			if m.pending[key] != timer {
+				return
+			}
+
+			delete(m.pending, key)
+
+			sw.Press(button, characteristic.ProgrammableSwitchEventSinglePress)
+		})
+
+		m.pending[key] = timer
+	}
+}
+
+// Start Run the polling in the background until Stop is called
+func (m *ButtonMonitor) Start() {
+	// a disabled interval or no switches mean, that no polling
+	// is wanted
+	if m.interval <= 0 || len(m.switches) == 0 {
+		return
+	}
+
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go m.run()
+}
+
+// Stop Stop the background polling and wait until it finished
+func (m *ButtonMonitor) Stop() {
+	// return early, if the polling was never started
+	if m.stop == nil {
+		return
+	}
+
+	close(m.stop)
+	<-m.done
+}
+
+func (m *ButtonMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			// poll the switches and log errors, as the next tick
+			// will try it again
+			if err := m.Poll(); err != nil {
+				log.Errorf("cannot poll switches: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package homekit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestButtonMonitor_Poll(t *testing.T) {
+	tests := []struct {
+		description       string
+		doublePressWindow time.Duration
+		wait              time.Duration
+		events            []int
+		expectedEvents    []int
+	}{
+		{
+			description:    "single press",
+			events:         []int{1002},
+			expectedEvents: []int{characteristic.ProgrammableSwitchEventSinglePress},
+		},
+		{
+			description:    "long press",
+			events:         []int{1000, 1001, 1003},
+			expectedEvents: []int{characteristic.ProgrammableSwitchEventLongPress},
+		},
+		{
+			description:    "native double press",
+			events:         []int{1004},
+			expectedEvents: []int{characteristic.ProgrammableSwitchEventDoublePress},
+		},
+		{
+			description:       "detected double press",
+			doublePressWindow: time.Minute,
+			events:            []int{1002, 1002},
+			expectedEvents:    []int{characteristic.ProgrammableSwitchEventDoublePress},
+		},
+		{
+			description:       "delayed single press",
+			doublePressWindow: 10 * time.Millisecond,
+			wait:              50 * time.Millisecond,
+			events:            []int{1002},
+			expectedEvents:    []int{characteristic.ProgrammableSwitchEventSinglePress},
+		},
+	}
+
+	for _, test := range tests {
+		mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+			Sensors: map[string]*hue.Sensor{
+				"5": {Type: hue.SensorTypeZHASwitch, Name: "Remote", State: &hue.SensorState{}},
+			},
+		})
+		simulator.AddUser("success")
+
+		bridge, err := hue.NewBridge(
+			strings.TrimPrefix(mockServer.URL, "https://"),
+			store.NewMemory(map[string]string{"bridge_username": "success"}),
+		)
+		assert.Nilf(t, err, test.description)
+
+		sw := NewProgrammableSwitch(accessory.Info{Name: "Remote"}, 4)
+
+		var mutex sync.Mutex
+		var events []int
+
+		sw.Buttons[0].ProgrammableSwitchEvent.OnValueUpdate(func(c *characteristic.Characteristic, new, old interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			events = append(events, new.(int))
+		})
+
+		monitor := NewButtonMonitor(bridge, 0, test.doublePressWindow)
+		monitor.Register(&hue.Sensor{ID: "5", Type: hue.SensorTypeZHASwitch, State: &hue.SensorState{}}, sw)
+
+		for i, event := range test.events {
+			simulator.SetSensorState("5", &hue.SensorState{
+				ButtonEvent: event,
+				LastUpdated: fmt.Sprintf("2020-06-01T20:12:%02d", i),
+			})
+
+			assert.Nilf(t, monitor.Poll(), test.description)
+		}
+
+		// wait for delayed single presses
+		time.Sleep(test.wait)
+
+		mutex.Lock()
+		assert.Equalf(t, test.expectedEvents, events, test.description)
+		mutex.Unlock()
+
+		mockServer.Close()
+	}
+}
@@ -0,0 +1,232 @@
+package homekit
+
+import (
+	"math"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/service"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/color"
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// ColorLight Represent a light, that is dimmable and can show colors,
+// but no color temperature
+type ColorLight struct {
+	*accessory.Accessory
+	Lightbulb *ColorLightService
+
+	// gamut Colors, that the light can show
+	gamut *color.Gamut
+}
+
+// NewColorLight Create a new accessory for the color light
+func NewColorLight(info accessory.Info) *ColorLight {
+	// initialize the accessory
+	acc := ColorLight{}
+
+	// set the base accessory with given information
+	acc.Accessory = accessory.New(info, accessory.TypeLightbulb)
+
+	// lights without a known gamut receive the colors unchanged
+	acc.gamut = color.GamutDefault
+
+	// set the color service
+	acc.Lightbulb = newColorLightService()
+
+	// register all services to the accessory itself
+	acc.AddService(acc.Lightbulb.Service)
+
+	return &acc
+}
+
+// UpdateState Update the characteristics with the state of the hue light
+func (acc *ColorLight) UpdateState(state *hue.State) {
+	acc.Lightbulb.On.SetValue(state.On)
+	acc.Lightbulb.Brightness.SetValue(brightnessToHomeKit(state.Brightness))
+
+	hue, saturation := colorToHomeKit(state, acc.gamut)
+
+	acc.Lightbulb.Hue.SetValue(hue)
+	acc.Lightbulb.Saturation.SetValue(saturation)
+}
+
+// ColorLightService Represent the services behind the color light bulb,
+// e.g. power state, brightness and color
+type ColorLightService struct {
+	*service.Service
+
+	On         *characteristic.On
+	Brightness *characteristic.Brightness
+	Hue        *characteristic.Hue
+	Saturation *characteristic.Saturation
+}
+
+func newColorLightService() *ColorLightService {
+	// instantiate the service and register it
+	svc := ColorLightService{}
+	svc.Service = service.New(service.TypeLightbulb)
+
+	// register the On characteristic for the power state
+	svc.On = characteristic.NewOn()
+	svc.AddCharacteristic(svc.On.Characteristic)
+
+	// register the brightness characteristic
+	svc.Brightness = characteristic.NewBrightness()
+	svc.AddCharacteristic(svc.Brightness.Characteristic)
+
+	// register the hue characteristic
+	svc.Hue = characteristic.NewHue()
+	svc.AddCharacteristic(svc.Hue.Characteristic)
+
+	// register the saturation characteristic
+	svc.Saturation = characteristic.NewSaturation()
+	svc.AddCharacteristic(svc.Saturation.Characteristic)
+
+	// return the custom service
+	return &svc
+}
+
+func createColorLightAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating color light accessory for: %s - %s", light.ID, light.Name)
+
+	// create the lightbulb accessory
+	ac := NewColorLight(accessory.Info{
+		ID:               id,
+		Name:             light.Name,
+		Model:            light.ModelID,
+		Manufacturer:     light.ManufacturerName,
+		FirmwareRevision: light.SoftwareVersion,
+	})
+
+	// convert the colors within the gamut of the light
+	ac.gamut = color.NewGamut(light.Gamut())
+
+	//
+	// Power State
+	//
+
+	// switch the light and fetch its power state
+	configurePowerState(id, light, bridge, ac.Lightbulb.On)
+
+	//
+	// Brightness
+	//
+
+	// configure what do to, when the home app changes the brightness
+	// of the light
+	ac.Lightbulb.Brightness.OnValueRemoteUpdate(func(bri int) {
+		bri = int(math.Floor(float64(bri)*254) / 100)
+
+		// send a toggle request
+		err := bridge.LightUpdateState(light, &hue.State{On: true, Brightness: bri})
+
+		log.WithFields(log.Fields{
+			"id":   id,
+			"name": light.Name,
+			"type": light.Type,
+		}).Debugf("change brightness: %d", bri)
+
+		// if an error occurred...
+		if err != nil {
+			// ...log it
+			log.WithFields(log.Fields{
+				"id":   id,
+				"name": light.Name,
+				"bri":  bri,
+				"on":   "brightness",
+			}).Errorf("%s", err.Error())
+		}
+	})
+
+	// configure what to do, when the home app fetches the brightness
+	// of the light
+	ac.Lightbulb.Brightness.OnValueRemoteGet(func() int {
+		// refetch the light information based on the id
+		l, err := bridge.Light(light.ID)
+
+		// return, that the light is of, if an error
+		// occurred
+		if err != nil {
+			return 0
+		}
+
+		// otherwise return the correct state
+		return brightnessToHomeKit(l.State.Brightness)
+	})
+
+	//
+	// Hue and Saturation
+	//
+
+	// homekit writes the hue and the saturation separately, so the
+	// color is set from the new value and the current value of the
+	// other characteristic
+	ac.Lightbulb.Hue.OnValueRemoteUpdate(func(value float64) {
+		setColor(id, light, bridge, ac.gamut, value, floatValue(ac.Lightbulb.Saturation.Characteristic))
+	})
+
+	// configure what to do, when the home app fetches the color
+	// of the light
+	ac.Lightbulb.Hue.OnValueRemoteGet(func() float64 {
+		// refetch the light information based on the id
+		l, err := bridge.Light(light.ID)
+
+		if err != nil {
+			return 0.0
+		}
+
+		value, _ := colorToHomeKit(l.State, ac.gamut)
+
+		return value
+	})
+
+	ac.Lightbulb.Saturation.OnValueRemoteUpdate(func(value float64) {
+		setColor(id, light, bridge, ac.gamut, floatValue(ac.Lightbulb.Hue.Characteristic), value)
+	})
+
+	ac.Lightbulb.Saturation.OnValueRemoteGet(func() float64 {
+		// refetch the light information based on the id
+		l, err := bridge.Light(light.ID)
+
+		if err != nil {
+			return 0.0
+		}
+
+		_, value := colorToHomeKit(l.State, ac.gamut)
+
+		return value
+	})
+
+	// return the configured accessory
+	return ac.Accessory, ac
+}
+
+// setColor Send the color from homekit as xy within the gamut of the
+// light, such that the light shows the picked color
+func setColor(id uint64, light *hue.Light, bridge hue.Bridger, gamut *color.Gamut, hueValue, saturation float64) {
+	xy := color.FromHSV(hueValue, saturation, gamut)
+
+	// send a color request
+	err := bridge.LightUpdateState(light, &hue.State{On: true, XY: []float64{xy.X, xy.Y}})
+
+	log.WithFields(log.Fields{
+		"id":   id,
+		"name": light.Name,
+		"type": light.Type,
+	}).Debugf("change color: hue %f, saturation %f, xy %f/%f", hueValue, saturation, xy.X, xy.Y)
+
+	// if an error occurred...
+	if err != nil {
+		// ...log it
+		log.WithFields(log.Fields{
+			"id":         id,
+			"name":       light.Name,
+			"hue":        hueValue,
+			"saturation": saturation,
+			"on":         "color",
+		}).Errorf("%s", err.Error())
+	}
+}
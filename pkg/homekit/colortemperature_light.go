@@ -2,7 +2,6 @@ package homekit
 
 import (
 	"math"
-	"strconv"
 
 	"github.com/brutella/hc/accessory"
 	"github.com/brutella/hc/characteristic"
@@ -35,6 +34,13 @@ func NewColorTemperatureLight(info accessory.Info) *ColorTemperatureLight {
 	return &acc
 }
 
+// UpdateState Update the characteristics with the state of the hue light
+func (acc *ColorTemperatureLight) UpdateState(state *hue.State) {
+	acc.Lightbulb.On.SetValue(state.On)
+	acc.Lightbulb.Brightness.SetValue(brightnessToHomeKit(state.Brightness))
+	acc.Lightbulb.ColorTemperature.SetValue(colorTemperatureToHomeKit(state.ColorTemperature))
+}
+
 // ColorTemperatureLightService Represent the services behind the color temperature light
 // bulb, e.g. power state and brightness
 type ColorTemperatureLightService struct {
@@ -66,16 +72,12 @@ func newColorTemperatureLightService() *ColorTemperatureLightService {
 	return &svc
 }
 
-func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger) *accessory.Accessory {
+func createColorTemperatureLightAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
 	log.Debugf("creating color temperature light accessory for: %s - %s", light.ID, light.Name)
 
-	// convert the id to an int. As hue's ids are integers, omit the error
-	// handling
-	id, _ := strconv.Atoi(light.ID)
-
 	// create the lightbulb accessory
 	ac := NewColorTemperatureLight(accessory.Info{
-		ID:               uint64(id + 1), // #nosec G115 IDs will always be smaller
+		ID:               id,
 		Name:             light.Name,
 		Model:            light.ModelID,
 		Manufacturer:     light.ManufacturerName,
@@ -86,45 +88,8 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 	// Power State
 	//
 
-	// configure what do to, when the home app changes the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteUpdate(func(on bool) {
-		// send a toggle request
-		err := bridge.LightUpdateState(light, &hue.State{On: on})
-
-		log.WithFields(log.Fields{
-			"id":   id + 1,
-			"name": light.Name,
-			"type": light.Type,
-		}).Debugf("trigger state: %t", on)
-
-		// if an error occurred...
-		if err != nil {
-			// ...log it
-			log.WithFields(log.Fields{
-				"id":    id + 1,
-				"name":  light.Name,
-				"state": on,
-				"on":    "on",
-			}).Errorf("%s", err.Error())
-		}
-	})
-
-	// configure what to do, when the home app fetches the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteGet(func() bool {
-		// refetch the light information based on the id
-		l, err := bridge.Light(light.ID)
-
-		// return, that the light is of, if an error
-		// occurred
-		if err != nil {
-			return false
-		}
-
-		// otherwise return the correct state
-		return l.State.On
-	})
+	// switch the light and fetch its power state
+	configurePowerState(id, light, bridge, ac.Lightbulb.On)
 
 	//
 	// Brightness
@@ -139,7 +104,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		err := bridge.LightUpdateState(light, &hue.State{On: true, Brightness: bri})
 
 		log.WithFields(log.Fields{
-			"id":   id + 1,
+			"id":   id,
 			"name": light.Name,
 			"type": light.Type,
 		}).Debugf("change brightness: %d", bri)
@@ -148,7 +113,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		if err != nil {
 			// ...log it
 			log.WithFields(log.Fields{
-				"id":   id + 1,
+				"id":   id,
 				"name": light.Name,
 				"bri":  bri,
 				"on":   "brightness",
@@ -169,7 +134,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		}
 
 		// otherwise return the correct state
-		return int(math.Floor(float64(l.State.Brightness*100) / 254))
+		return brightnessToHomeKit(l.State.Brightness)
 	})
 
 	//
@@ -186,7 +151,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		err := bridge.LightUpdateState(light, &hue.State{On: true, ColorTemperature: colorTemperature})
 
 		log.WithFields(log.Fields{
-			"id":   id + 1,
+			"id":   id,
 			"name": light.Name,
 			"type": light.Type,
 		}).Debugf("change color-temperature: %d", colorTemperature)
@@ -195,7 +160,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		if err != nil {
 			// ...log it
 			log.WithFields(log.Fields{
-				"id":               id + 1,
+				"id":               id,
 				"name":             light.Name,
 				"colorTemperature": colorTemperature,
 				"on":               "color-temperature",
@@ -206,7 +171,7 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 	// configure what to do, when the home app fetches the color temperature
 	// of the light
 	// hue range for color temperature 153 - 500 [mired]
-	ac.Lightbulb.Brightness.OnValueRemoteGet(func() int {
+	ac.Lightbulb.ColorTemperature.OnValueRemoteGet(func() int {
 		// refetch the light information based on the id
 		l, err := bridge.Light(light.ID)
 
@@ -217,9 +182,9 @@ func createColorTemperatureLightAccessory(light *hue.Light, bridge hue.Bridger)
 		}
 
 		// otherwise return the correct state
-		return int(math.Min(500, math.Max(153, float64(l.State.ColorTemperature))))
+		return colorTemperatureToHomeKit(l.State.ColorTemperature)
 	})
 
 	// return the configured accessory
-	return ac.Accessory
+	return ac.Accessory, ac
 }
@@ -0,0 +1,72 @@
+package homekit
+
+import (
+	"math"
+
+	"github.com/brutella/hc/characteristic"
+
+	"github.com/dj95/huekit/pkg/color"
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// brightnessToHomeKit Convert the hue brightness (1 - 254) into the
+// homekit brightness (0 - 100 [%])
+func brightnessToHomeKit(bri int) int {
+	return int(math.Floor(float64(bri*100) / 254))
+}
+
+// colorTemperatureToHomeKit Clamp the hue color temperature into the
+// hue range for color temperature 153 - 500 [mired]
+func colorTemperatureToHomeKit(colorTemperature int) int {
+	return int(math.Min(500, math.Max(153, float64(colorTemperature))))
+}
+
+// hueToHomeKit Convert the hue (0 - 65535) into the homekit hue
+// (0 - 360 [°]). A missing hue is red.
+func hueToHomeKit(color *int) float64 {
+	if color == nil {
+		return 0
+	}
+
+	return float64(*color) * 360 / 65535
+}
+
+// saturationToHomeKit Convert the hue saturation (0 - 254) into the
+// homekit saturation (0 - 100 [%]). A missing saturation is white.
+func saturationToHomeKit(saturation *int) float64 {
+	if saturation == nil {
+		return 0
+	}
+
+	return float64(*saturation) * 100 / 254
+}
+
+// colorToHomeKit Convert the color of the hue light into the homekit hue
+// (0 - 360 [°]) and saturation (0 - 100 [%]) based on the color mode, that
+// the light currently uses
+func colorToHomeKit(state *hue.State, gamut *color.Gamut) (float64, float64) {
+	switch {
+	case state.ColorMode == "xy" && len(state.XY) == 2:
+		return color.ToHSV(color.Point{X: state.XY[0], Y: state.XY[1]}, gamut)
+	case state.ColorMode == "ct":
+		return color.ToHSV(color.FromColorTemperature(state.ColorTemperature), gamut)
+	case state.ColorMode == "hs":
+		return hueToHomeKit(state.Hue), saturationToHomeKit(state.Saturation)
+	case len(state.XY) == 2:
+		return color.ToHSV(color.Point{X: state.XY[0], Y: state.XY[1]}, gamut)
+	default:
+		return hueToHomeKit(state.Hue), saturationToHomeKit(state.Saturation)
+	}
+}
+
+// floatValue Return the local value of a float characteristic without
+// fetching it from the bridge
+func floatValue(c *characteristic.Characteristic) float64 {
+	value, ok := c.Value.(float64)
+
+	if !ok {
+		return 0
+	}
+
+	return value
+}
@@ -2,7 +2,6 @@ package homekit
 
 import (
 	"math"
-	"strconv"
 
 	"github.com/brutella/hc/accessory"
 	"github.com/brutella/hc/characteristic"
@@ -35,6 +34,12 @@ func NewDimmableLightbulb(info accessory.Info) *DimmableLightbulb {
 	return &acc
 }
 
+// UpdateState Update the characteristics with the state of the hue light
+func (acc *DimmableLightbulb) UpdateState(state *hue.State) {
+	acc.Lightbulb.On.SetValue(state.On)
+	acc.Lightbulb.Brightness.SetValue(brightnessToHomeKit(state.Brightness))
+}
+
 // DimmableLightbulbService Represent the services behind the dimmable light
 // bulb, e.g. power state and brightness
 type DimmableLightbulbService struct {
@@ -61,16 +66,12 @@ func newDimmableLightbulbService() *DimmableLightbulbService {
 	return &svc
 }
 
-func createDimmableLightAccessory(light *hue.Light, bridge hue.Bridger) *accessory.Accessory {
+func createDimmableLightAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
 	log.Debugf("creating dimmable light accessory for: %s - %s", light.ID, light.Name)
 
-	// convert the id to an int. As hue's ids are integers, omit the error
-	// handling
-	id, _ := strconv.Atoi(light.ID)
-
 	// create the lightbulb accessory
 	ac := NewDimmableLightbulb(accessory.Info{
-		ID:               uint64(id + 1), // #nosec G115 IDs will always be smaller
+		ID:               id,
 		Name:             light.Name,
 		Model:            light.ModelID,
 		FirmwareRevision: light.SoftwareVersion,
@@ -80,45 +81,8 @@ func createDimmableLightAccessory(light *hue.Light, bridge hue.Bridger) *accesso
 	// Power State
 	//
 
-	// configure what do to, when the home app changes the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteUpdate(func(on bool) {
-		// send a toggle request
-		err := bridge.LightUpdateState(light, &hue.State{On: on})
-
-		log.WithFields(log.Fields{
-			"id":   id + 1,
-			"name": light.Name,
-			"type": light.Type,
-		}).Debugf("trigger state: %t", on)
-
-		// if an error occurred...
-		if err != nil {
-			// ...log it
-			log.WithFields(log.Fields{
-				"id":    id + 1,
-				"name":  light.Name,
-				"state": on,
-				"on":    "on",
-			}).Errorf("%s", err.Error())
-		}
-	})
-
-	// configure what to do, when the home app fetches the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteGet(func() bool {
-		// refetch the light information based on the id
-		l, err := bridge.Light(light.ID)
-
-		// return, that the light is of, if an error
-		// occurred
-		if err != nil {
-			return false
-		}
-
-		// otherwise return the correct state
-		return l.State.On
-	})
+	// switch the light and fetch its power state
+	configurePowerState(id, light, bridge, ac.Lightbulb.On)
 
 	//
 	// Brightness
@@ -133,7 +97,7 @@ func createDimmableLightAccessory(light *hue.Light, bridge hue.Bridger) *accesso
 		err := bridge.LightUpdateState(light, &hue.State{On: true, Brightness: bri})
 
 		log.WithFields(log.Fields{
-			"id":   id + 1,
+			"id":   id,
 			"name": light.Name,
 			"type": light.Type,
 		}).Debugf("change brightness: %d", bri)
@@ -142,7 +106,7 @@ func createDimmableLightAccessory(light *hue.Light, bridge hue.Bridger) *accesso
 		if err != nil {
 			// ...log it
 			log.WithFields(log.Fields{
-				"id":   id + 1,
+				"id":   id,
 				"name": light.Name,
 				"bri":  bri,
 				"on":   "brightness",
@@ -163,9 +127,9 @@ func createDimmableLightAccessory(light *hue.Light, bridge hue.Bridger) *accesso
 		}
 
 		// otherwise return the correct state
-		return int(math.Floor(float64(l.State.Brightness*100) / 254))
+		return brightnessToHomeKit(l.State.Brightness)
 	})
 
 	// return the configured accessory
-	return ac.Accessory
+	return ac.Accessory, ac
 }
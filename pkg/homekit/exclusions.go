@@ -0,0 +1,97 @@
+package homekit
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// excludedLightPrefix Prefix of the keys, that mark lights as excluded
+// in the store
+const excludedLightPrefix = "excluded_light/"
+
+// Exclusions Lights, that were excluded at runtime, e.g. via the api.
+// They are persisted in the store, such that they stay excluded after
+// a restart.
+type Exclusions struct {
+	store store.Store
+}
+
+// NewExclusions Create new exclusions, that are persisted in the store
+func NewExclusions(store store.Store) *Exclusions {
+	return &Exclusions{
+		store: store,
+	}
+}
+
+// Exclude Exclude the light of the bridge with the given key
+func (e *Exclusions) Exclude(bridge, id string) error {
+	return e.store.Set(exclusionKey(bridge, id), strconv.FormatBool(true))
+}
+
+// Include Revert the exclusion of the light of the bridge with the
+// given key
+func (e *Exclusions) Include(bridge, id string) error {
+	return e.store.Set(exclusionKey(bridge, id), strconv.FormatBool(false))
+}
+
+// Excluded Check, if the light of the bridge with the given key was
+// excluded. Nil exclusions exclude no light.
+func (e *Exclusions) Excluded(bridge, id string) bool {
+	if e == nil {
+		return false
+	}
+
+	value, err := e.store.Get(exclusionKey(bridge, id))
+
+	// lights without entry are not excluded
+	if err != nil {
+		return false
+	}
+
+	excluded, _ := strconv.ParseBool(value)
+
+	return excluded
+}
+
+// migrateExclusions Move the exclusions of the lights from one bridge key
+// to another. Exclusions, that already exist with the new key, are kept.
+func migrateExclusions(store store.Store, from, to string) error {
+	keys, err := store.Keys(exclusionKey(from, ""))
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		next := exclusionKey(to, strings.TrimPrefix(key, exclusionKey(from, "")))
+
+		if _, err := store.Get(next); err == nil {
+			continue
+		}
+
+		value, err := store.Get(key)
+
+		// error handling
+		if err != nil {
+			return err
+		}
+
+		if err := store.Set(next, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exclusionKey Return the key of the light in the store
+func exclusionKey(bridge, id string) string {
+	if bridge == "" {
+		return excludedLightPrefix + id
+	}
+
+	return excludedLightPrefix + bridge + "/" + id
+}
@@ -0,0 +1,67 @@
+package homekit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestExclusions(t *testing.T) {
+	memory := store.NewMemory(nil)
+	exclusions := NewExclusions(memory)
+
+	assert.Nil(t, exclusions.Exclude("", "1"))
+	assert.Nil(t, exclusions.Exclude("lab", "2"))
+	assert.Nil(t, exclusions.Exclude("lab", "3"))
+	assert.Nil(t, exclusions.Include("lab", "3"))
+
+	tests := []struct {
+		description      string
+		exclusions       *Exclusions
+		bridge           string
+		id               string
+		expectedExcluded bool
+	}{
+		{
+			description:      "excluded light of the single bridge",
+			exclusions:       exclusions,
+			bridge:           "",
+			id:               "1",
+			expectedExcluded: true,
+		},
+		{
+			description:      "light with the same id on another bridge",
+			exclusions:       exclusions,
+			bridge:           "lab",
+			id:               "1",
+			expectedExcluded: false,
+		},
+		{
+			description:      "excluded light of a named bridge",
+			exclusions:       exclusions,
+			bridge:           "lab",
+			id:               "2",
+			expectedExcluded: true,
+		},
+		{
+			description:      "included again",
+			exclusions:       exclusions,
+			bridge:           "lab",
+			id:               "3",
+			expectedExcluded: false,
+		},
+		{
+			description:      "no exclusions",
+			exclusions:       nil,
+			bridge:           "",
+			id:               "1",
+			expectedExcluded: false,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.expectedExcluded, test.exclusions.Excluded(test.bridge, test.id), test.description)
+	}
+}
@@ -2,13 +2,13 @@ package homekit
 
 import (
 	"math"
-	"strconv"
 
 	"github.com/brutella/hc/accessory"
 	"github.com/brutella/hc/characteristic"
 	"github.com/brutella/hc/service"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/dj95/huekit/pkg/color"
 	"github.com/dj95/huekit/pkg/hue"
 )
 
@@ -16,6 +16,9 @@ import (
 type ExtendedColorLight struct {
 	*accessory.Accessory
 	Lightbulb *ExtendedColorLightService
+
+	// gamut Colors, that the light can show
+	gamut *color.Gamut
 }
 
 // NewExtendendColorLight Create a new accessory for the CCT light
@@ -26,6 +29,9 @@ func NewExtendendColorLight(info accessory.Info) *ExtendedColorLight {
 	// set the base accessory with given information
 	acc.Accessory = accessory.New(info, accessory.TypeLightbulb)
 
+	// lights without a known gamut receive the colors unchanged
+	acc.gamut = color.GamutDefault
+
 	// set the dimmable service
 	acc.Lightbulb = newExtendedColorLightService()
 
@@ -35,6 +41,18 @@ func NewExtendendColorLight(info accessory.Info) *ExtendedColorLight {
 	return &acc
 }
 
+// UpdateState Update the characteristics with the state of the hue light
+func (acc *ExtendedColorLight) UpdateState(state *hue.State) {
+	acc.Lightbulb.On.SetValue(state.On)
+	acc.Lightbulb.Brightness.SetValue(brightnessToHomeKit(state.Brightness))
+	acc.Lightbulb.ColorTemperature.SetValue(colorTemperatureToHomeKit(state.ColorTemperature))
+
+	hue, saturation := colorToHomeKit(state, acc.gamut)
+
+	acc.Lightbulb.Hue.SetValue(hue)
+	acc.Lightbulb.Saturation.SetValue(saturation)
+}
+
 // ExtendedColorLightService Represent the services behind the color temperature light
 // bulb, e.g. power state and brightness
 type ExtendedColorLightService struct {
@@ -64,69 +82,39 @@ func newExtendedColorLightService() *ExtendedColorLightService {
 	svc.ColorTemperature = characteristic.NewColorTemperature()
 	svc.AddCharacteristic(svc.ColorTemperature.Characteristic)
 
+	// register the hue characteristic
+	svc.Hue = characteristic.NewHue()
+	svc.AddCharacteristic(svc.Hue.Characteristic)
+
+	// register the saturation characteristic
+	svc.Saturation = characteristic.NewSaturation()
+	svc.AddCharacteristic(svc.Saturation.Characteristic)
+
 	// return the custom service
 	return &svc
 }
 
-func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *accessory.Accessory {
+func createExtendedColorLightAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
 	log.Debugf("creating extended color light accessory for: %s - %s", light.ID, light.Name)
 
-	// convert the id to an int. As hue's ids are integers, omit the error
-	// handling
-	id, _ := strconv.Atoi(light.ID)
-
 	// create the lightbulb accessory
 	ac := NewExtendendColorLight(accessory.Info{
-		ID:               uint64(id + 1), // #nosec G115 IDs will always be smaller
+		ID:               id,
 		Name:             light.Name,
 		Model:            light.ModelID,
 		Manufacturer:     light.ManufacturerName,
 		FirmwareRevision: light.SoftwareVersion,
 	})
 
+	// convert the colors within the gamut of the light
+	ac.gamut = color.NewGamut(light.Gamut())
+
 	//
 	// Power State
 	//
 
-	// configure what do to, when the home app changes the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteUpdate(func(on bool) {
-		// send a toggle request
-		err := bridge.LightUpdateState(light, &hue.State{On: on})
-
-		log.WithFields(log.Fields{
-			"id":   id + 1,
-			"name": light.Name,
-			"type": light.Type,
-		}).Debugf("trigger state: %t", on)
-
-		// if an error occurred...
-		if err != nil {
-			// ...log it
-			log.WithFields(log.Fields{
-				"id":    id + 1,
-				"name":  light.Name,
-				"state": on,
-				"on":    "on",
-			}).Errorf("%s", err.Error())
-		}
-	})
-
-	// configure what to do, when the home app fetches the state
-	// of the light
-	ac.Lightbulb.On.OnValueRemoteGet(func() bool {
-		// refetch the light information based on the id
-		l, err := bridge.Light(light.ID)
-
-		// return, that the light is of, if an error
-		// occurred
-		if err != nil {
-			return false
-		}
-
-		// otherwise return the correct state
-		return l.State.On
-	})
+	// switch the light and fetch its power state
+	configurePowerState(id, light, bridge, ac.Lightbulb.On)
 
 	//
 	// Brightness
@@ -141,7 +129,7 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		err := bridge.LightUpdateState(light, &hue.State{On: true, Brightness: bri})
 
 		log.WithFields(log.Fields{
-			"id":   id + 1,
+			"id":   id,
 			"name": light.Name,
 			"type": light.Type,
 		}).Debugf("change brightness: %d", bri)
@@ -150,7 +138,7 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		if err != nil {
 			// ...log it
 			log.WithFields(log.Fields{
-				"id":   id + 1,
+				"id":   id,
 				"name": light.Name,
 				"bri":  bri,
 				"on":   "brightness",
@@ -171,7 +159,7 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		}
 
 		// otherwise return the correct state
-		return int(math.Floor(float64(l.State.Brightness*100) / 254))
+		return brightnessToHomeKit(l.State.Brightness)
 	})
 
 	//
@@ -188,7 +176,7 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		err := bridge.LightUpdateState(light, &hue.State{On: true, ColorTemperature: colorTemperature})
 
 		log.WithFields(log.Fields{
-			"id":   id + 1,
+			"id":   id,
 			"name": light.Name,
 			"type": light.Type,
 		}).Debugf("change color-temperature: %d", colorTemperature)
@@ -197,7 +185,7 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		if err != nil {
 			// ...log it
 			log.WithFields(log.Fields{
-				"id":               id + 1,
+				"id":               id,
 				"name":             light.Name,
 				"colorTemperature": colorTemperature,
 				"on":               "color-temperature",
@@ -219,85 +207,52 @@ func createExtendedColorLightAccessory(light *hue.Light, bridge hue.Bridger) *ac
 		}
 
 		// otherwise return the correct state
-		return int(math.Min(500, math.Max(153, float64(l.State.ColorTemperature))))
+		return colorTemperatureToHomeKit(l.State.ColorTemperature)
 	})
 
 	//
-	// Hue
+	// Hue and Saturation
 	//
 
-	ac.Lightbulb.Hue.OnValueRemoteUpdate(func(color float64) {
-		color = math.Min(0, math.Max(360, float64(color)))
-
-		// send a toggle request
-		err := bridge.LightUpdateState(light, &hue.State{On: true, Hue: int(color)})
-
-		log.WithFields(log.Fields{
-			"id":   id + 1,
-			"name": light.Name,
-			"type": light.Type,
-		}).Debugf("change hue: %f", color)
-
-		// if an error occurred...
-		if err != nil {
-			// ...log it
-			log.WithFields(log.Fields{
-				"id":               id + 1,
-				"name":             light.Name,
-				"colorTemperature": color,
-				"on":               "hue",
-			}).Errorf("%s", err.Error())
-		}
+	// homekit writes the hue and the saturation separately, so the
+	// color is set from the new value and the current value of the
+	// other characteristic
+	ac.Lightbulb.Hue.OnValueRemoteUpdate(func(value float64) {
+		setColor(id, light, bridge, ac.gamut, value, floatValue(ac.Lightbulb.Saturation.Characteristic))
 	})
 
+	// configure what to do, when the home app fetches the color
+	// of the light
 	ac.Lightbulb.Hue.OnValueRemoteGet(func() float64 {
+		// refetch the light information based on the id
 		l, err := bridge.Light(light.ID)
 
 		if err != nil {
 			return 0.0
 		}
 
-		return math.Min(0, math.Max(360, float64(l.State.Hue)))
-	})
+		value, _ := colorToHomeKit(l.State, ac.gamut)
 
-	//
-	// Saturation
-	//
-
-	ac.Lightbulb.Saturation.OnValueRemoteUpdate(func(saturation float64) {
-		saturation = math.Min(0, math.Max(100, float64(saturation)))
-
-		// send a toggle request
-		err := bridge.LightUpdateState(light, &hue.State{On: true, Saturation: int(saturation)})
-
-		log.WithFields(log.Fields{
-			"id":   id + 1,
-			"name": light.Name,
-			"type": light.Type,
-		}).Debugf("change saturation: %f", saturation)
+		return value
+	})
 
-		// if an error occurred...
-		if err != nil {
-			// ...log it
-			log.WithFields(log.Fields{
-				"id":         id + 1,
-				"name":       light.Name,
-				"saturation": saturation,
-				"on":         "saturation",
-			}).Errorf("%s", err.Error())
-		}
+	ac.Lightbulb.Saturation.OnValueRemoteUpdate(func(value float64) {
+		setColor(id, light, bridge, ac.gamut, floatValue(ac.Lightbulb.Hue.Characteristic), value)
 	})
 
 	ac.Lightbulb.Saturation.OnValueRemoteGet(func() float64 {
+		// refetch the light information based on the id
 		l, err := bridge.Light(light.ID)
 
 		if err != nil {
 			return 0.0
 		}
 
-		return math.Min(0, math.Max(100, float64(l.State.Saturation)))
+		_, value := colorToHomeKit(l.State, ac.gamut)
+
+		return value
 	})
 
 	// return the configured accessory
-	return ac.Accessory
+	return ac.Accessory, ac
 }
@@ -0,0 +1,108 @@
+package homekit
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/color"
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestCreateExtendedColorLightAccessory(t *testing.T) {
+	light := &hue.Light{
+		ID:    "1",
+		Type:  "Extended color light",
+		Name:  "Hue Go",
+		State: &hue.State{},
+		Capabilities: &hue.Capabilities{
+			Control: &hue.Control{ColorGamutType: "C"},
+		},
+	}
+
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{"1": light},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	_, updater := createExtendedColorLightAccessory(2, light, bridge)
+	acc := updater.(*ExtendedColorLight)
+
+	assert.Equal(t, color.GamutC, acc.gamut)
+
+	// the connection of the iOS device, that changes the light
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	// homekit picks a saturated red, which is clamped into gamut c
+	acc.Lightbulb.Saturation.UpdateValueFromConnection(100.0, conn)
+	acc.Lightbulb.Hue.UpdateValueFromConnection(0.0, conn)
+
+	state := simulator.Light("1").State
+
+	assert.True(t, state.On)
+	assert.Equal(t, "xy", state.ColorMode)
+	assert.InDelta(t, 0.6915, state.XY[0], 0.0001)
+	assert.InDelta(t, 0.3083, state.XY[1], 0.0001)
+
+	// the color is read back from the xy color
+	assert.InDelta(t, 9.6, acc.Lightbulb.Hue.GetValueFromConnection(conn), 0.1)
+	assert.InDelta(t, 100.0, acc.Lightbulb.Saturation.GetValueFromConnection(conn), 0.1)
+}
+
+func TestColorToHomeKit(t *testing.T) {
+	tests := []struct {
+		description        string
+		state              *hue.State
+		expectedHue        float64
+		expectedSaturation float64
+	}{
+		{
+			description:        "xy color mode",
+			state:              &hue.State{ColorMode: "xy", XY: []float64{0.2744, 0.312}, Hue: hue.Int(100), Saturation: hue.Int(100)},
+			expectedHue:        200,
+			expectedSaturation: 20,
+		},
+		{
+			description:        "hs color mode",
+			state:              &hue.State{ColorMode: "hs", XY: []float64{0.2744, 0.312}, Hue: hue.Int(21845), Saturation: hue.Int(127)},
+			expectedHue:        120,
+			expectedSaturation: 50,
+		},
+		{
+			description:        "cold white of the ct color mode",
+			state:              &hue.State{ColorMode: "ct", ColorTemperature: 153},
+			expectedSaturation: 4.5,
+		},
+		{
+			description:        "no color mode",
+			state:              &hue.State{XY: []float64{0.2744, 0.312}},
+			expectedHue:        200,
+			expectedSaturation: 20,
+		},
+	}
+
+	for _, test := range tests {
+		hue, saturation := colorToHomeKit(test.state, color.GamutC)
+
+		// the hue is meaningless for white
+		if test.expectedHue > 0 {
+			assert.InDeltaf(t, test.expectedHue, hue, 0.1, test.description)
+		}
+
+		assert.InDeltaf(t, test.expectedSaturation, saturation, 0.1, test.description)
+	}
+}
@@ -0,0 +1,266 @@
+package homekit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/service"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// TypeFadeDuration Custom characteristic, that sets the duration of the
+// fade for the next change of a light
+const TypeFadeDuration = "A9B50F7C-8C62-4E46-9D2B-6875656B6974"
+
+// fadeWindow Duration after a write of the fade duration, in which the
+// changes of the light use it. Homekit writes all characteristics of a
+// scene or automation at once, but not in a defined order.
+const fadeWindow = 2 * time.Second
+
+// defaultBridgeFade Duration of the fades, if no transition time is
+// sent to the bridge
+const defaultBridgeFade = 400 * time.Millisecond
+
+// maxFadeDuration Longest fade, that the hue bridge supports
+const maxFadeDuration = 6553.5
+
+// fadingAccessoryTypes Accessory types, that can fade between states.
+// Lights, that can only be switched, do not fade.
+var fadingAccessoryTypes = map[string]bool{
+	"dimmable light":          true,
+	"color temperature light": true,
+	"color light":             true,
+	"color dimmable light":    true,
+	"extended color light":    true,
+	"group":                   true,
+}
+
+// Fades Default durations of the fades, that are used for the changes
+// from homekit. The durations are keyed by the accessory type, the id or
+// the unique id of the light. The bridge decides, if none matches.
+type Fades struct {
+	defaultFade *time.Duration
+	fades       map[string]time.Duration
+}
+
+// NewFades Create the fades from the configured default and the
+// durations per accessory type or light. An empty default uses the
+// default of the bridge. An error is returned for invalid durations.
+func NewFades(defaultFade string, fades map[string]string) (*Fades, error) {
+	result := &Fades{
+		fades: map[string]time.Duration{},
+	}
+
+	// parse the default fade
+	if defaultFade != "" {
+		duration, err := parseFade(defaultFade)
+
+		// error handling
+		if err != nil {
+			return nil, fmt.Errorf("invalid default transition time: %s", err.Error())
+		}
+
+		result.defaultFade = &duration
+	}
+
+	for key, value := range fades {
+		duration, err := parseFade(value)
+
+		// error handling
+		if err != nil {
+			return nil, fmt.Errorf("invalid transition time for '%s': %s", key, err.Error())
+		}
+
+		// types and unique ids are compared case-insensitive
+		result.fades[strings.ToLower(key)] = duration
+	}
+
+	return result, nil
+}
+
+// parseFade Parse the duration of a fade in the range of the bridge
+func parseFade(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+
+	// error handling
+	if err != nil {
+		return 0, err
+	}
+
+	if duration < 0 || duration.Seconds() > maxFadeDuration {
+		return 0, fmt.Errorf("%s is not between 0s and %.1fs", value, maxFadeDuration)
+	}
+
+	return duration, nil
+}
+
+// fade Return the default fade of a light with the accessory type. The
+// unique id is preferred over the id, which is preferred over the type.
+// Nil is returned, if the bridge should decide.
+func (f *Fades) fade(light *hue.Light, accessoryType string) *time.Duration {
+	if f == nil {
+		return nil
+	}
+
+	var keys []string
+
+	// groups are only configured by their type
+	if light != nil {
+		if light.UniqueID != "" {
+			keys = append(keys, strings.ToLower(light.UniqueID))
+		}
+
+		keys = append(keys, light.ID)
+	}
+
+	for _, key := range append(keys, accessoryType) {
+		if duration, ok := f.fades[key]; ok {
+			return &duration
+		}
+	}
+
+	return f.defaultFade
+}
+
+// fadingBridge Add the transition time to the changes of an accessory.
+// The default fade is used, unless homekit set the fade duration for
+// the next change.
+type fadingBridge struct {
+	hue.Bridger
+
+	defaultFade *time.Duration
+
+	mutex sync.Mutex
+	next  time.Duration
+	until time.Time
+}
+
+// newFadingBridge Wrap the bridge, such that the changes fade with the
+// given default duration. The bridge decides, if it is nil.
+func newFadingBridge(bridge hue.Bridger, defaultFade *time.Duration) *fadingBridge {
+	return &fadingBridge{
+		Bridger:     bridge,
+		defaultFade: defaultFade,
+	}
+}
+
+// setNext Use the duration for the changes in the fade window
+func (b *fadingBridge) setNext(duration time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.next = duration
+	b.until = time.Now().Add(fadeWindow)
+}
+
+// current Return the duration of the fade for a change at the moment
+func (b *fadingBridge) current() *time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if time.Now().Before(b.until) {
+		next := b.next
+
+		return &next
+	}
+
+	return b.defaultFade
+}
+
+// seconds Return the duration of the fade for a change at the moment
+// in seconds. The bridge fades for 400ms by default.
+func (b *fadingBridge) seconds() float64 {
+	if fade := b.current(); fade != nil {
+		return fade.Seconds()
+	}
+
+	return defaultBridgeFade.Seconds()
+}
+
+// withFade Copy the state and add the transition time, if the state has
+// none yet
+func (b *fadingBridge) withFade(state *hue.State) *hue.State {
+	fade := b.current()
+
+	if state == nil || state.TransitionTime != nil || fade == nil {
+		return state
+	}
+
+	result := *state
+	result.TransitionTime = hue.TransitionTime(*fade)
+
+	return &result
+}
+
+// LightUpdateState Update the light with the transition time
+func (b *fadingBridge) LightUpdateState(light *hue.Light, state *hue.State) error {
+	return b.Bridger.LightUpdateState(light, b.withFade(state))
+}
+
+// GroupUpdateAction Update the group with the transition time
+func (b *fadingBridge) GroupUpdateAction(group *hue.Group, action *hue.State) error {
+	return b.Bridger.GroupUpdateAction(group, b.withFade(action))
+}
+
+// NewFadeDuration Create the characteristic, that sets the duration of
+// the fade in seconds for the next change
+func NewFadeDuration() *characteristic.Float {
+	c := characteristic.NewFloat(TypeFadeDuration)
+	c.Format = characteristic.FormatFloat
+	c.Perms = characteristic.PermsAll()
+	c.Unit = characteristic.UnitSeconds
+	c.Description = "Fade Duration"
+	c.SetMinValue(0)
+	c.SetMaxValue(maxFadeDuration)
+	c.SetStepValue(0.1)
+	c.SetValue(defaultBridgeFade.Seconds())
+
+	return c
+}
+
+// configureFade Add the fade duration to the first service of the
+// accessory, that is not the accessory information
+func configureFade(acc *accessory.Accessory, bridge *fadingBridge) {
+	fade := NewFadeDuration()
+	fade.SetValue(bridge.seconds())
+
+	var mutex sync.Mutex
+	var reset *time.Timer
+
+	// the written duration is used for the next change
+	fade.OnValueRemoteUpdate(func(value float64) {
+		bridge.setNext(time.Duration(value * float64(time.Second)))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// show the default again after the window, as writes of an
+		// unchanged value are ignored and the next write of the same
+		// duration would be lost otherwise
+		if reset != nil {
+			reset.Stop()
+		}
+
+		reset = time.AfterFunc(fadeWindow, func() {
+			fade.UpdateValue(bridge.seconds())
+		})
+	})
+
+	// report the duration of the next change
+	fade.OnValueRemoteGet(bridge.seconds)
+
+	for _, svc := range acc.Services {
+		if svc.Type == service.TypeAccessoryInformation {
+			continue
+		}
+
+		svc.AddCharacteristic(fade.Characteristic)
+
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package homekit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brutella/hc/characteristic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// recordingBridge Record the states, that are sent to the bridge
+type recordingBridge struct {
+	hue.Bridger
+
+	states []*hue.State
+}
+
+func (b *recordingBridge) LightUpdateState(light *hue.Light, state *hue.State) error {
+	b.states = append(b.states, state)
+
+	return nil
+}
+
+func (b *recordingBridge) GroupUpdateAction(group *hue.Group, action *hue.State) error {
+	b.states = append(b.states, action)
+
+	return nil
+}
+
+// transitionTime Return the transition time of the last recorded state
+func (b *recordingBridge) transitionTime() *int {
+	return b.states[len(b.states)-1].TransitionTime
+}
+
+func TestNewFades(t *testing.T) {
+	fades, err := NewFades("1s", map[string]string{
+		"Group":                      "0s",
+		"extended color light":       "2s",
+		"5":                          "3s",
+		"00:17:88:01:02:03:04:AB-0b": "4s",
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description    string
+		fades          *Fades
+		light          *hue.Light
+		accessoryType  string
+		expectedResult time.Duration
+		expectedNil    bool
+	}{
+		{
+			description:    "default",
+			fades:          fades,
+			light:          &hue.Light{ID: "1"},
+			accessoryType:  "dimmable light",
+			expectedResult: time.Second,
+		},
+		{
+			description:    "accessory type",
+			fades:          fades,
+			light:          &hue.Light{ID: "1"},
+			accessoryType:  "extended color light",
+			expectedResult: 2 * time.Second,
+		},
+		{
+			description:    "id before the type",
+			fades:          fades,
+			light:          &hue.Light{ID: "5"},
+			accessoryType:  "extended color light",
+			expectedResult: 3 * time.Second,
+		},
+		{
+			description:    "unique id before the id",
+			fades:          fades,
+			light:          &hue.Light{ID: "5", UniqueID: "00:17:88:01:02:03:04:ab-0b"},
+			accessoryType:  "extended color light",
+			expectedResult: 4 * time.Second,
+		},
+		{
+			description:    "group",
+			fades:          fades,
+			accessoryType:  "group",
+			expectedResult: 0,
+		},
+		{
+			description:   "not configured",
+			fades:         nil,
+			light:         &hue.Light{ID: "1"},
+			accessoryType: "dimmable light",
+			expectedNil:   true,
+		},
+	}
+
+	for _, test := range tests {
+		result := test.fades.fade(test.light, test.accessoryType)
+
+		assert.Equalf(t, test.expectedNil, result == nil, test.description)
+
+		if result != nil {
+			assert.Equalf(t, test.expectedResult, *result, test.description)
+		}
+	}
+
+	// invalid durations are rejected
+	_, err = NewFades("soon", nil)
+	assert.NotNil(t, err)
+
+	_, err = NewFades("", map[string]string{"group": "2h"})
+	assert.NotNil(t, err)
+}
+
+func TestConfigureFade(t *testing.T) {
+	recorder := &recordingBridge{}
+	defaultFade := time.Second
+
+	fade := newFadingBridge(recorder, &defaultFade)
+
+	acc, _ := createDimmableLightAccessory(2, &hue.Light{ID: "1", Name: "Desk"}, fade)
+	configureFade(acc, fade)
+
+	// the fade duration is added to the lightbulb
+	lightbulb := acc.Services[1]
+	brightness := lightbulb.Characteristics[1]
+	duration := lightbulb.Characteristics[len(lightbulb.Characteristics)-1]
+
+	assert.Equal(t, TypeFadeDuration, duration.Type)
+	assert.Equal(t, characteristic.UnitSeconds, duration.Unit)
+
+	// the connection of the iOS device, that changes the light
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	assert.Equal(t, 1.0, duration.GetValueFromConnection(conn))
+
+	// changes use the default fade
+	brightness.UpdateValueFromConnection(50, conn)
+
+	assert.Equal(t, 10, *recorder.transitionTime())
+
+	// a written fade duration is used for the next change
+	duration.UpdateValueFromConnection(1800.0, conn)
+	brightness.UpdateValueFromConnection(100, conn)
+
+	assert.Equal(t, 18000, *recorder.transitionTime())
+	assert.Equal(t, 1800.0, duration.GetValueFromConnection(conn))
+
+	// the default is used again after the window
+	fade.until = time.Now()
+	brightness.UpdateValueFromConnection(20, conn)
+
+	assert.Equal(t, 10, *recorder.transitionTime())
+
+	// transition times of the state are kept
+	assert.Nil(t, fade.LightUpdateState(&hue.Light{ID: "1"}, &hue.State{TransitionTime: hue.TransitionTime(0)}))
+	assert.Equal(t, 0, *recorder.transitionTime())
+
+	// without default, the bridge decides
+	group := newFadingBridge(recorder, nil)
+
+	assert.Nil(t, group.GroupUpdateAction(&hue.Group{ID: "1"}, &hue.State{On: true}))
+	assert.Nil(t, recorder.transitionTime())
+	assert.Equal(t, 0.4, group.seconds())
+}
@@ -0,0 +1,56 @@
+package homekit
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/service"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// Fan Represent a fan, that is connected to a light or plug and can only
+// be switched on and off
+type Fan struct {
+	*accessory.Accessory
+	Fan *service.Fan
+}
+
+// NewFan Create a new accessory for the fan
+func NewFan(info accessory.Info) *Fan {
+	// initialize the accessory
+	acc := Fan{}
+
+	// set the base accessory with given information
+	acc.Accessory = accessory.New(info, accessory.TypeFan)
+
+	// set the fan service
+	acc.Fan = service.NewFan()
+
+	// register all services to the accessory itself
+	acc.AddService(acc.Fan.Service)
+
+	return &acc
+}
+
+// UpdateState Update the characteristics with the state of the hue light
+func (acc *Fan) UpdateState(state *hue.State) {
+	acc.Fan.On.SetValue(state.On)
+}
+
+func createFanAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating fan accessory for: %s - %s", light.ID, light.Name)
+
+	// create the fan accessory
+	ac := NewFan(accessory.Info{
+		ID:               id,
+		Name:             light.Name,
+		Model:            light.ModelID,
+		Manufacturer:     light.ManufacturerName,
+		FirmwareRevision: light.SoftwareVersion,
+	})
+
+	configurePowerState(id, light, bridge, ac.Fan.On)
+
+	// return the configured accessory
+	return ac.Accessory, ac
+}
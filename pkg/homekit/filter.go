@@ -0,0 +1,169 @@
+package homekit
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// FilterRule Matches lights by their properties. A light matches the
+// rule, if all set properties match.
+type FilterRule struct {
+	// Bridge Name of the hue bridge in the bridges list. Rules without
+	// bridge match the lights of every bridge.
+	Bridge string `mapstructure:"bridge"`
+
+	// ID Id of the light on the hue bridge. Ids are only unique per
+	// bridge, so the rule matches the id on every bridge, unless the
+	// bridge is set.
+	ID string `mapstructure:"id"`
+
+	// Name Glob pattern, e.g. "Kitchen *", of the light name
+	Name string `mapstructure:"name"`
+
+	// Model Regular expression of the model id
+	Model string `mapstructure:"model"`
+
+	// Manufacturer Name of the manufacturer, compared case-insensitive
+	Manufacturer string `mapstructure:"manufacturer"`
+
+	// Type Type of the light, e.g. "Dimmable light", compared
+	// case-insensitive
+	Type string `mapstructure:"type"`
+}
+
+// filterRule A validated rule with its compiled model pattern
+type filterRule struct {
+	FilterRule
+
+	model *regexp.Regexp
+}
+
+// Filter Decides, which lights are bridged. Excluded lights are never
+// bridged, included lights are always bridged, even genuine hue devices.
+// All other lights are bridged, if they are not from hue.
+type Filter struct {
+	include []*filterRule
+	exclude []*filterRule
+}
+
+// NewFilter Create a new filter with the given include and exclude
+// rules. An error is returned for empty rules and invalid patterns.
+func NewFilter(include, exclude []FilterRule) (*Filter, error) {
+	includeRules, err := compileFilterRules(include)
+
+	// error handling
+	if err != nil {
+		return nil, fmt.Errorf("invalid include rule: %w", err)
+	}
+
+	excludeRules, err := compileFilterRules(exclude)
+
+	// error handling
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude rule: %w", err)
+	}
+
+	return &Filter{
+		include: includeRules,
+		exclude: excludeRules,
+	}, nil
+}
+
+// Bridged Check, if the light of the bridge with the given key should be
+// published in homekit and return the reason for the decision. A nil
+// filter bridges all lights, that are not genuine hue devices.
+func (f *Filter) Bridged(bridge string, light *hue.Light) (bool, string) {
+	if f != nil {
+		// excluded lights are never bridged
+		for index, rule := range f.exclude {
+			if rule.matches(bridge, light) {
+				return false, fmt.Sprintf("matches exclude rule %d", index+1)
+			}
+		}
+
+		// included lights are always bridged
+		for index, rule := range f.include {
+			if rule.matches(bridge, light) {
+				return true, fmt.Sprintf("matches include rule %d", index+1)
+			}
+		}
+	}
+
+	// genuine hue devices are published by the hue bridge itself
+	classification := light.Classify()
+
+	return !classification.Genuine, classification.Reason
+}
+
+// compileFilterRules Validate the rules and compile their patterns
+func compileFilterRules(rules []FilterRule) ([]*filterRule, error) {
+	var result []*filterRule
+
+	for _, rule := range rules {
+		// a rule without properties would match every light
+		if rule == (FilterRule{}) {
+			return nil, fmt.Errorf("the rule has no properties")
+		}
+
+		// check the glob pattern
+		if _, err := path.Match(rule.Name, ""); err != nil {
+			return nil, fmt.Errorf("name '%s': %w", rule.Name, err)
+		}
+
+		compiled := &filterRule{
+			FilterRule: rule,
+		}
+
+		// compile the model pattern
+		if rule.Model != "" {
+			model, err := regexp.Compile(rule.Model)
+
+			// error handling
+			if err != nil {
+				return nil, fmt.Errorf("model '%s': %w", rule.Model, err)
+			}
+
+			compiled.model = model
+		}
+
+		result = append(result, compiled)
+	}
+
+	return result, nil
+}
+
+// matches Check, if all set properties of the rule match the light of
+// the bridge with the given key
+func (r *filterRule) matches(bridge string, light *hue.Light) bool {
+	if r.Bridge != "" && !strings.EqualFold(r.Bridge, bridge) {
+		return false
+	}
+
+	if r.ID != "" && r.ID != light.ID {
+		return false
+	}
+
+	if r.Name != "" {
+		if matched, _ := path.Match(r.Name, light.Name); !matched {
+			return false
+		}
+	}
+
+	if r.model != nil && !r.model.MatchString(light.ModelID) {
+		return false
+	}
+
+	if r.Manufacturer != "" && !strings.EqualFold(r.Manufacturer, light.ManufacturerName) {
+		return false
+	}
+
+	if r.Type != "" && !strings.EqualFold(r.Type, light.Type) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,171 @@
+package homekit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		description   string
+		include       []FilterRule
+		exclude       []FilterRule
+		expectedError bool
+	}{
+		{
+			description:   "no rules",
+			expectedError: false,
+		},
+		{
+			description:   "valid rules",
+			include:       []FilterRule{{Model: "^LCT0[0-9]{2}$"}},
+			exclude:       []FilterRule{{Name: "Kitchen *", Type: "Dimmable light"}},
+			expectedError: false,
+		},
+		{
+			description:   "empty rule",
+			include:       []FilterRule{{}},
+			expectedError: true,
+		},
+		{
+			description:   "invalid glob",
+			exclude:       []FilterRule{{Name: "[Kitchen"}},
+			expectedError: true,
+		},
+		{
+			description:   "invalid regular expression",
+			include:       []FilterRule{{Model: "(LCT"}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewFilter(test.include, test.exclude)
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+	}
+}
+
+func TestFilter_Bridged(t *testing.T) {
+	filter, err := NewFilter(
+		[]FilterRule{
+			{ID: "3"},
+			{Manufacturer: "signify netherlands b.v.", Type: "Extended color light"},
+			{Bridge: "lab", ID: "6"},
+		},
+		[]FilterRule{
+			{Name: "Garage *"},
+			{Model: "^TRADFRI bulb E14"},
+			{Bridge: "office", ID: "7"},
+		},
+	)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description    string
+		filter         *Filter
+		bridge         string
+		light          *hue.Light
+		expectedResult bool
+	}{
+		{
+			description:    "third party light without filter",
+			filter:         nil,
+			light:          &hue.Light{ID: "1", ModelID: "TRADFRI bulb E27 W opal 1000lm"},
+			expectedResult: true,
+		},
+		{
+			description:    "hue light without filter",
+			filter:         nil,
+			light:          &hue.Light{ID: "2", ModelID: "LCT015"},
+			expectedResult: false,
+		},
+		{
+			description:    "third party light without matching rule",
+			filter:         filter,
+			light:          &hue.Light{ID: "1", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm"},
+			expectedResult: true,
+		},
+		{
+			description:    "excluded by name",
+			filter:         filter,
+			light:          &hue.Light{ID: "1", Name: "Garage Door", ModelID: "TRADFRI bulb E27 W opal 1000lm"},
+			expectedResult: false,
+		},
+		{
+			description:    "excluded by model",
+			filter:         filter,
+			light:          &hue.Light{ID: "1", Name: "Desk", ModelID: "TRADFRI bulb E14 WS opal 400lm"},
+			expectedResult: false,
+		},
+		{
+			description:    "hue light included by id",
+			filter:         filter,
+			light:          &hue.Light{ID: "3", Name: "Hallway", ModelID: "LWB010"},
+			expectedResult: true,
+		},
+		{
+			description:    "hue light included by manufacturer and type",
+			filter:         filter,
+			light:          &hue.Light{ID: "4", ModelID: "LCT015", ManufacturerName: "Signify Netherlands B.V.", Type: "Extended color light"},
+			expectedResult: true,
+		},
+		{
+			description:    "hue light with another type",
+			filter:         filter,
+			light:          &hue.Light{ID: "5", ModelID: "LWB010", ManufacturerName: "Signify Netherlands B.V.", Type: "Dimmable light"},
+			expectedResult: false,
+		},
+		{
+			description:    "exclude rules win over include rules",
+			filter:         filter,
+			light:          &hue.Light{ID: "3", Name: "Garage Ceiling", ModelID: "LWB010"},
+			expectedResult: false,
+		},
+		{
+			description:    "id without bridge matches on every bridge",
+			filter:         filter,
+			bridge:         "lab",
+			light:          &hue.Light{ID: "3", Name: "Hallway", ModelID: "LWB010"},
+			expectedResult: true,
+		},
+		{
+			description:    "hue light included by id on its bridge",
+			filter:         filter,
+			bridge:         "lab",
+			light:          &hue.Light{ID: "6", Name: "Desk", ModelID: "LWB010"},
+			expectedResult: true,
+		},
+		{
+			description:    "hue light with the id on another bridge",
+			filter:         filter,
+			bridge:         "office",
+			light:          &hue.Light{ID: "6", Name: "Desk", ModelID: "LWB010"},
+			expectedResult: false,
+		},
+		{
+			description:    "excluded by id on its bridge",
+			filter:         filter,
+			bridge:         "office",
+			light:          &hue.Light{ID: "7", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm"},
+			expectedResult: false,
+		},
+		{
+			description:    "third party light with the id on another bridge",
+			filter:         filter,
+			bridge:         "lab",
+			light:          &hue.Light{ID: "7", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm"},
+			expectedResult: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, reason := test.filter.Bridged(test.bridge, test.light)
+
+		assert.Equalf(t, test.expectedResult, result, test.description)
+		assert.NotEmptyf(t, reason, test.description)
+	}
+}
@@ -0,0 +1,132 @@
+package homekit
+
+import (
+	"math"
+
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// groupState Combine the state of a group into a light state, such that
+// it can be pushed into the accessory
+func groupState(group *hue.Group) *hue.State {
+	state := &hue.State{}
+
+	// the group is on, if any of its lights is on
+	if group.State != nil {
+		state.On = group.State.AnyOn
+	}
+
+	// the brightness is the last brightness set for the group
+	if group.Action != nil {
+		state.Brightness = group.Action.Brightness
+	}
+
+	return state
+}
+
+func createGroupAccessory(id uint64, group *hue.Group, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating group accessory for: %s - %s", group.ID, group.Name)
+
+	// create the lightbulb accessory
+	ac := NewDimmableLightbulb(accessory.Info{
+		ID:           id,
+		Name:         group.Name,
+		Model:        group.Type,
+		Manufacturer: "HueKit",
+	})
+
+	//
+	// Power State
+	//
+
+	// configure what do to, when the home app changes the state
+	// of the group
+	ac.Lightbulb.On.OnValueRemoteUpdate(func(on bool) {
+		// send a single request for all lights of the group
+		err := bridge.GroupUpdateAction(group, &hue.State{On: on})
+
+		log.WithFields(log.Fields{
+			"id":   id,
+			"name": group.Name,
+			"type": group.Type,
+		}).Debugf("trigger group state: %t", on)
+
+		// if an error occurred...
+		if err != nil {
+			// ...log it
+			log.WithFields(log.Fields{
+				"id":    id,
+				"name":  group.Name,
+				"state": on,
+				"on":    "on",
+			}).Errorf("%s", err.Error())
+		}
+	})
+
+	// configure what to do, when the home app fetches the state
+	// of the group
+	ac.Lightbulb.On.OnValueRemoteGet(func() bool {
+		// refetch the group information based on the id
+		g, err := bridge.Group(group.ID)
+
+		// return, that the group is off, if an error
+		// occurred
+		if err != nil {
+			return false
+		}
+
+		// otherwise return the correct state
+		return groupState(g).On
+	})
+
+	//
+	// Brightness
+	//
+
+	// configure what do to, when the home app changes the brightness
+	// of the group
+	ac.Lightbulb.Brightness.OnValueRemoteUpdate(func(bri int) {
+		bri = int(math.Floor(float64(bri)*254) / 100)
+
+		// send a single request for all lights of the group
+		err := bridge.GroupUpdateAction(group, &hue.State{On: true, Brightness: bri})
+
+		log.WithFields(log.Fields{
+			"id":   id,
+			"name": group.Name,
+			"type": group.Type,
+		}).Debugf("change group brightness: %d", bri)
+
+		// if an error occurred...
+		if err != nil {
+			// ...log it
+			log.WithFields(log.Fields{
+				"id":   id,
+				"name": group.Name,
+				"bri":  bri,
+				"on":   "brightness",
+			}).Errorf("%s", err.Error())
+		}
+	})
+
+	// configure what to do, when the home app fetches the brightness
+	// of the group
+	ac.Lightbulb.Brightness.OnValueRemoteGet(func() int {
+		// refetch the group information based on the id
+		g, err := bridge.Group(group.ID)
+
+		// return zero, if an error occurred
+		if err != nil {
+			return 0
+		}
+
+		// otherwise return the correct brightness
+		return brightnessToHomeKit(groupState(g).Brightness)
+	})
+
+	// return the configured accessory
+	return ac.Accessory, ac
+}
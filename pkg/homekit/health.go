@@ -0,0 +1,90 @@
+package homekit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotPublished Returned by Ready, until the ip transport of the
+// homekit bridge is running
+var ErrNotPublished = errors.New("the homekit bridge is not published yet")
+
+// Ready Return an error, if the ip transport of the homekit bridge does
+// not accept connections. The bridges are authenticated, once the server
+// exists.
+func (s *Server) Ready() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.current == nil || !s.current.listening() {
+		return ErrNotPublished
+	}
+
+	return nil
+}
+
+// Healthy Return an error, if a hue bridge was unreachable for longer
+// than the unreachable timeout
+func (s *Server) Healthy() error {
+	// a disabled timeout means, that unreachable bridges are fine
+	if s.config.UnreachableTimeout <= 0 {
+		return nil
+	}
+
+	s.contactMutex.Lock()
+	defer s.contactMutex.Unlock()
+
+	for _, bridge := range s.bridges {
+		since := time.Since(s.contacts[bridge.Key]).Truncate(time.Second)
+
+		if since <= s.config.UnreachableTimeout {
+			continue
+		}
+
+		// a single bridge has no key
+		if bridge.Key == "" {
+			return fmt.Errorf("the hue bridge is unreachable for %s", since)
+		}
+
+		return fmt.Errorf("the hue bridge '%s' is unreachable for %s", bridge.Key, since)
+	}
+
+	return nil
+}
+
+// probe Query the lights of all bridges in a quarter of the unreachable
+// timeout, until the server is stopped. The bridges are probed, as
+// bridges with the event stream or without synchronization are not
+// queried otherwise.
+func (s *Server) probe() {
+	ticker := time.NewTicker(s.config.UnreachableTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			for _, bridge := range s.bridges {
+				if _, err := bridge.Bridge.Lights(); err != nil {
+					log.Warnf("cannot reach bridge '%s': %s", bridge.Key, err.Error())
+
+					continue
+				}
+
+				s.contacted(bridge.Key)
+			}
+		}
+	}
+}
+
+// contacted Save the current time as last contact with the bridge
+func (s *Server) contacted(key string) {
+	s.contactMutex.Lock()
+	defer s.contactMutex.Unlock()
+
+	s.contacts[key] = time.Now()
+}
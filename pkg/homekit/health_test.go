@@ -0,0 +1,86 @@
+package homekit
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestServer_Healthy(t *testing.T) {
+	tests := []struct {
+		description   string
+		timeout       time.Duration
+		bridges       []HueBridge
+		lastContact   time.Duration
+		expectedError error
+	}{
+		{
+			description:   "disabled",
+			timeout:       0,
+			bridges:       []HueBridge{{}},
+			lastContact:   time.Hour,
+			expectedError: nil,
+		},
+		{
+			description:   "reachable",
+			timeout:       5 * time.Minute,
+			bridges:       []HueBridge{{}},
+			lastContact:   time.Minute,
+			expectedError: nil,
+		},
+		{
+			description:   "unreachable",
+			timeout:       5 * time.Minute,
+			bridges:       []HueBridge{{}},
+			lastContact:   10 * time.Minute,
+			expectedError: errors.New("the hue bridge is unreachable for 10m0s"),
+		},
+		{
+			description:   "named bridge unreachable",
+			timeout:       5 * time.Minute,
+			bridges:       []HueBridge{{Key: "office"}},
+			lastContact:   10 * time.Minute,
+			expectedError: errors.New("the hue bridge 'office' is unreachable for 10m0s"),
+		},
+	}
+
+	for _, test := range tests {
+		server := NewServer(Config{UnreachableTimeout: test.timeout}, test.bridges, store.NewMemory(nil))
+
+		for _, bridge := range test.bridges {
+			server.contacts[bridge.Key] = time.Now().Add(-test.lastContact)
+		}
+
+		assert.Equalf(t, test.expectedError, server.Healthy(), test.description)
+	}
+}
+
+func TestServer_Ready(t *testing.T) {
+	server := NewServer(Config{}, nil, store.NewMemory(nil))
+
+	// nothing is published before run
+	assert.Equal(t, ErrNotPublished, server.Ready())
+
+	server.current = &publication{}
+	assert.Equal(t, ErrNotPublished, server.Ready())
+
+	// the transport is started, but does not listen yet
+	port, err := freePort()
+	assert.Nil(t, err)
+
+	server.current.port = port
+	server.current.running.Store(true)
+	assert.Equal(t, ErrNotPublished, server.Ready())
+
+	// the transport listens on its port
+	listener, err := net.Listen("tcp", ":"+port)
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	assert.Nil(t, server.Ready())
+}
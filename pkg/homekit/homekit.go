@@ -2,6 +2,13 @@
 package homekit
 
 import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/brutella/hc"
 	"github.com/brutella/hc/accessory"
 	log "github.com/sirupsen/logrus"
@@ -9,77 +16,653 @@ import (
 	"github.com/dj95/huekit/pkg/hue"
 )
 
-// StartBridge Create a bridge, required accessories and start the bridge
-func StartBridge(pin string, port string, lights []*hue.Light, bridge hue.Bridger) {
-	// create the bridge accessory
-	bridgeAccessory := accessory.NewBridge(accessory.Info{
-		ID:   1,
-		Name: "HueKit Bridge",
-	})
-
-	// create the lights based on the hue lights without a matching
-	// modelID
-	accessories := configureLights(lights, bridge)
-
-	// create the ip transport, that publishes homekit functionality
-	// and acts as the bridge
-	t, err := hc.NewIPTransport(
-		hc.Config{Port: port, Pin: pin},
-		bridgeAccessory.Accessory,
-		accessories[:]...,
-	)
+// HueBridge A hue bridge, whose devices are published
+type HueBridge struct {
+	// Key Identifies the bridge in the accessory ids of devices
+	// without unique id. Empty, if only one bridge is used
+	Key string
+
+	// Bridge Connection to the hue bridge
+	Bridge hue.Bridger
+}
+
+// LightStatus A light of a hue bridge with the decision, if it is
+// published in homekit
+type LightStatus struct {
+	// Bridge Key of the hue bridge, that the light is paired to
+	Bridge string
+
+	// Light The light as it was discovered
+	Light *hue.Light
+
+	// AccessoryID Id of the accessory. Zero, if the light is skipped
+	AccessoryID uint64
+
+	// Bridged Set, if the light is published in homekit
+	Bridged bool
+
+	// Reason Explanation, why the light is published or skipped
+	Reason string
+}
+
+// Config Configuration of the homekit bridge
+type Config struct {
+	// Pin, that must be entered in homekit for pairing
+	Pin string
+
+	// Port, the bridge listens on. A random port is used, when empty
+	Port string
+
+	// Filter Decides, which lights are published. Lights, that are
+	// not from hue, are published, when it is nil
+	Filter *Filter
+
+	// Services Overrides the homekit service of single lights, e.g.
+	// in order to publish a plug for a lamp as lightbulb
+	Services ServiceOverrides
+
+	// Fades Default durations of the fades for the changes from
+	// homekit. The bridge decides, when it is nil
+	Fades *Fades
+
+	// Groups Ids or names of the rooms and zones, that should be
+	// published as lightbulbs
+	Groups []string
+
+	// Scenes Ids or names of the scenes, that should be published
+	// as switches
+	Scenes []string
+
+	// Sensors Bridge third party motion, temperature, light level
+	// and contact sensors as well as switches
+	Sensors bool
+
+	// ButtonPollInterval Interval, in which the switches are polled
+	// for new button events
+	ButtonPollInterval time.Duration
+
+	// DoublePressWindow Maximum duration between two presses of a
+	// button, that are emitted as double press. Disabled, when zero
+	DoublePressWindow time.Duration
+
+	// SyncInterval Interval, in which light states are fetched from
+	// the hue bridge and pushed to homekit. Disabled, when zero
+	SyncInterval time.Duration
+
+	// ReloadInterval Interval, in which the devices are discovered
+	// again, in order to publish new and remove deleted devices.
+	// Disabled, when zero
+	ReloadInterval time.Duration
+
+	// UnreachableTimeout Duration, after which huekit is reported as
+	// unhealthy, if a hue bridge cannot be reached. Disabled, when
+	// zero
+	UnreachableTimeout time.Duration
+}
+
+// StartBridge Publish the accessories of the server until the process
+// is terminated. The devices are discovered again in the reload interval
+// and on SIGHUP.
+func StartBridge(server *Server) {
+	// enable graceful exit for the homekit bridge
+	hc.OnTermination(server.Stop)
+
+	// reload the devices on request
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			log.Info("reloading the devices")
+
+			server.Reload()
+		}
+	}()
+
+	// start the communication
+	if err := server.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// device A device of a hue bridge, that is published as accessory.
+// Exactly one of the light, group, scene or sensor is set.
+type device struct {
+	// id Id of the accessory
+	id uint64
+
+	// kind Type of the accessory. Accessories of the same kind have
+	// the same services and characteristics.
+	kind string
+
+	// bridge Index of the hue bridge, that the device is paired to
+	bridge int
+
+	light  *hue.Light
+	group  *hue.Group
+	scene  *hue.Scene
+	sensor *hue.Sensor
+
+	// create Builder of the accessory of a light
+	create func(uint64, *hue.Light, hue.Bridger) (*accessory.Accessory, StateUpdater)
+}
+
+// info Return the name and the firmware version, that homekit shows for
+// the accessory of the device
+func (d *device) info() (string, string) {
+	switch {
+	case d.light != nil:
+		return d.light.Name, d.light.SoftwareVersion
+	case d.group != nil:
+		return d.group.Name, ""
+	case d.scene != nil:
+		return d.scene.Name, ""
+	default:
+		return d.sensor.Name, d.sensor.SoftwareVersion
+	}
+}
+
+// discovery The devices of all hue bridges, that should be published,
+// and the decisions for all lights. Discovering the devices allocates
+// the ids of new devices, but creates no accessories, such that it can
+// be compared with the published devices cheaply.
+type discovery struct {
+	devices []*device
+	lights  []*LightStatus
+}
+
+// signature Return a description of the devices, that changes, when
+// devices are added, removed, renamed or change their kind. Renamed
+// devices are published again, as the handlers of the accessories log
+// and send the name of their device. The hash of the hc container is
+// not used, as it assigns new instance ids to the accessories.
+func (d *discovery) signature() string {
+	var builder strings.Builder
+
+	for _, device := range d.devices {
+		name, _ := device.info()
+
+		fmt.Fprintf(&builder, "%d:%s:%s;", device.id, device.kind, name)
+	}
+
+	return builder.String()
+}
+
+// discoverBridges Discover the devices of all hue bridges, that should be
+// published. An error is returned, if any resource cannot be fetched, as
+// a partial discovery would unpublish the missing devices.
+func discoverBridges(config Config, bridges []HueBridge, allocator *IDAllocator, exclusions *Exclusions) (*discovery, error) {
+	result := &discovery{}
+
+	for index, bridge := range bridges {
+		// allocate the ids of the devices in the namespace of the
+		// bridge. The lights of the first bridge keep their legacy ids.
+		ids := &bridgeIDs{
+			allocator: allocator,
+			namespace: bridge.Key,
+			legacy:    index == 0,
+		}
+
+		devices, lights, err := discoverBridge(config, bridge, ids, exclusions)
+
+		// error handling
+		if err != nil {
+			if bridge.Key == "" {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("bridge '%s': %w", bridge.Key, err)
+		}
+
+		// remember the bridge of the devices
+		for _, device := range devices {
+			device.bridge = index
+		}
+
+		result.devices = append(result.devices, devices...)
+		result.lights = append(result.lights, lights...)
+	}
+
+	return result, nil
+}
+
+// discoverBridge Discover the devices of a hue bridge, that should be
+// published. The decisions for all lights are returned as well.
+func discoverBridge(config Config, hueBridge HueBridge, ids *bridgeIDs, exclusions *Exclusions) ([]*device, []*LightStatus, error) {
+	bridge := hueBridge.Bridge
+
+	// discover the lights, that pass the filter
+	devices, lights, err := discoverLights(config, exclusions, hueBridge, ids)
 
 	// error handling
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
-	// enable graceful exit for the homekit bridge
-	hc.OnTermination(func() {
-		<-t.Stop()
-	})
+	// discover the selected rooms and zones
+	if len(config.Groups) > 0 {
+		groups, err := discoverGroups(config.Groups, bridge, ids)
 
-	// start the communication
-	t.Start()
+		// error handling
+		if err != nil {
+			return nil, nil, err
+		}
+
+		devices = append(devices, groups...)
+	}
+
+	// discover the third party sensors
+	if config.Sensors {
+		sensors, err := discoverSensors(bridge, ids)
+
+		// error handling
+		if err != nil {
+			return nil, nil, err
+		}
+
+		devices = append(devices, sensors...)
+	}
+
+	// discover the selected scenes
+	if len(config.Scenes) > 0 {
+		scenes, err := discoverScenes(config.Scenes, bridge, ids)
+
+		// error handling
+		if err != nil {
+			return nil, nil, err
+		}
+
+		devices = append(devices, scenes...)
+	}
+
+	return devices, lights, nil
 }
 
-func configureLights(lights []*hue.Light, bridge hue.Bridger) []*accessory.Accessory {
-	// initialize the accessories
-	var accessories []*accessory.Accessory
+// configureBridges Create the accessories of the discovered devices with
+// the synchronizers and button monitors for all hue bridges
+func configureBridges(config Config, bridges []HueBridge, discovered *discovery) *publication {
+	result := &publication{
+		lights:    discovered.lights,
+		signature: discovered.signature(),
+	}
+
+	for _, bridge := range bridges {
+		// create the synchronizer, that pushes state changes from the
+		// hue bridge into the accessories
+		result.synchronizers = append(result.synchronizers, NewSynchronizer(bridge, config.SyncInterval))
+
+		// create the monitor, that emits button events of switches
+		result.buttonMonitors = append(result.buttonMonitors, NewButtonMonitor(bridge.Bridge, config.ButtonPollInterval, config.DoublePressWindow))
+	}
+
+	for _, device := range discovered.devices {
+		bridge := bridges[device.bridge].Bridge
+		synchronizer := result.synchronizers[device.bridge]
+
+		var acc *accessory.Accessory
+
+		// create the accessory based on the device
+		switch {
+		case device.light != nil:
+			acc = configureLight(config, device, bridge, synchronizer)
+		case device.group != nil:
+			acc = configureGroup(config.Fades, device, bridge, synchronizer)
+		case device.scene != nil:
+			acc = createSceneAccessory(device.id, device.scene, instrument(bridge, "scene"))
+		default:
+			acc = configureSensor(device, synchronizer, result.buttonMonitors[device.bridge])
+		}
+
+		result.accessories = append(result.accessories, acc)
+	}
+
+	return result
+}
+
+func discoverLights(config Config, exclusions *Exclusions, hueBridge HueBridge, ids *bridgeIDs) ([]*device, []*LightStatus, error) {
+	bridge := hueBridge.Bridge
+
+	// initialize the devices and decisions
+	var devices []*device
+	var statuses []*LightStatus
+
+	// fetch all lights
+	lights, err := bridge.Lights()
+
+	// error handling
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot fetch lights: %w", err)
+	}
+
+	// devices with multiple lights cannot identify a single light
+	shared := sharedDevices(lights)
 
 	// iterate through all hue lights
 	for _, light := range lights {
-		// check, if the light has a model is from hue
-		if hue.ModelIDIsFromHue(light.ModelID) {
+		// check, if the light should be bridged. Lights, that were
+		// excluded at runtime, are skipped before the filter is asked
+		bridged, reason := false, "excluded via the api"
+
+		if !exclusions.Excluded(hueBridge.Key, light.ID) {
+			bridged, reason = config.Filter.Bridged(hueBridge.Key, light)
+		}
+
+		log.WithFields(log.Fields{
+			"id":               light.ID,
+			"name":             light.Name,
+			"type":             light.Type,
+			"model":            light.ModelID,
+			"manufacturer":     light.ManufacturerName,
+			"software_version": light.SoftwareVersion,
+			"bridged":          bridged,
+			"reason":           reason,
+		}).Debug("found device")
+
+		status := &LightStatus{
+			Bridge:  hueBridge.Key,
+			Light:   light,
+			Bridged: bridged,
+			Reason:  reason,
+		}
+
+		statuses = append(statuses, status)
+
+		if !bridged {
 			continue
 		}
 
-		var acc *accessory.Accessory
+		// choose the accessory based on the service and the type
+		create, accessoryType := chooseLightAccessory(light, config.Services.service(hueBridge.Key, light))
 
-		// create the accessory based on the type
-		switch light.Type {
-		case "On/Off plug-in unit":
-			acc = createUnitAccessory(light, bridge)
-		case "Dimmable light":
-			acc = createDimmableLightAccessory(light, bridge)
-		case "Color temperature light":
-			acc = createColorTemperatureLightAccessory(light, bridge)
-		case "Extended color light":
-			acc = createExtendedColorLightAccessory(light, bridge)
-		default:
-			acc = nil
+		if create == nil {
 			log.Infof("currently type: '%s' is not supported. Please create an issue, if you need support for it: https://github.com/dj95/huekit/issues", light.Type)
+
+			status.Bridged = false
+			status.Reason = fmt.Sprintf("type '%s' is not supported", light.Type)
+
+			continue
+		}
+
+		// allocate the accessory id
+		id, err := ids.light(light, shared[deviceAddress(light.UniqueID)])
+
+		// error handling
+		if err != nil {
+			log.Errorf("cannot allocate an accessory id for light %s: %s", light.ID, err.Error())
+
+			status.Bridged = false
+			status.Reason = "cannot allocate an accessory id"
+
+			continue
+		}
+
+		status.AccessoryID = id
+
+		devices = append(devices, &device{
+			id:     id,
+			kind:   accessoryType,
+			light:  light,
+			create: create,
+		})
+	}
+
+	// return all discovered devices
+	return devices, statuses, nil
+}
+
+// configureLight Create the accessory of a light, that receives its state
+// changes from the synchronizer
+func configureLight(config Config, device *device, bridge hue.Bridger, synchronizer *Synchronizer) *accessory.Accessory {
+	light := device.light
+
+	// fade the changes of lights, that can dim
+	var fade *fadingBridge
+	accessoryBridge := bridge
+
+	if fadingAccessoryTypes[device.kind] {
+		fade = newFadingBridge(bridge, config.Fades.fade(light, device.kind))
+		accessoryBridge = fade
+	}
+
+	// count the reads and writes of homekit
+	acc, updater := device.create(device.id, light, instrument(accessoryBridge, device.kind))
+
+	// allow homekit to set the fade of the next change
+	if fade != nil {
+		configureFade(acc, fade)
+	}
+
+	// receive state changes from the bridge
+	synchronizer.Register(light.ID, updater)
+
+	return acc
+}
+
+// chooseLightAccessory Return the builder of the accessory for the light
+// and the type of the accessory. Plugs are published as outlets, unless
+// the service is overridden. Nil is returned for unsupported types.
+func chooseLightAccessory(light *hue.Light, service string) (func(uint64, *hue.Light, hue.Bridger) (*accessory.Accessory, StateUpdater), string) {
+	switch service {
+	case ServiceOutlet:
+		return createOutletAccessory, "outlet"
+	case ServiceSwitch:
+		return createSwitchAccessory, "switch"
+	case ServiceFan:
+		return createFanAccessory, "fan"
+	}
+
+	switch light.Type {
+	case "On/Off plug-in unit":
+		// plugs often power lamps, which should be a lightbulb
+		if service == ServiceLightbulb {
+			return createOnOffLightAccessory, "on/off light"
+		}
+
+		return createOutletAccessory, "outlet"
+	case "On/Off light":
+		return createOnOffLightAccessory, "on/off light"
+	case "Dimmable light":
+		return createDimmableLightAccessory, "dimmable light"
+	case "Color temperature light":
+		return createColorTemperatureLightAccessory, "color temperature light"
+	case "Color light":
+		return createColorLightAccessory, "color light"
+	case "Color dimmable light":
+		return createColorLightAccessory, "color dimmable light"
+	case "Extended color light":
+		return createExtendedColorLightAccessory, "extended color light"
+	}
+
+	return nil, ""
+}
+
+func discoverSensors(bridge hue.Bridger, ids *bridgeIDs) ([]*device, error) {
+	// initialize the devices
+	var devices []*device
+
+	// fetch all sensors
+	sensors, err := bridge.Sensors()
+
+	// error handling
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch sensors: %w", err)
+	}
+
+	// iterate through all hue sensors
+	for _, sensor := range sensors {
+		// genuine hue sensors are published by the hue bridge itself
+		if classification := sensor.Classify(); classification.Genuine {
+			log.Debugf("skipping sensor %s - %s: %s", sensor.ID, sensor.Name, classification.Reason)
+
+			continue
+		}
+
+		// only supported sensors get an accessory id
+		_, isSwitch := switchButtons[sensor.Type]
+
+		if !isSwitch && !sensorTypes[sensor.Type] {
+			log.Debugf("sensor type: '%s' of %s - %s is not supported", sensor.Type, sensor.ID, sensor.Name)
+
+			continue
+		}
+
+		// allocate the accessory id
+		id, err := ids.sensor(sensor)
+
+		// error handling
+		if err != nil {
+			log.Errorf("cannot allocate an accessory id for sensor %s: %s", sensor.ID, err.Error())
+
+			continue
 		}
 
-		// if the type does not match, continue
-		if acc == nil {
+		devices = append(devices, &device{
+			id:     id,
+			kind:   "sensor " + sensor.Type,
+			sensor: sensor,
+		})
+	}
+
+	// return all discovered devices
+	return devices, nil
+}
+
+// configureSensor Create the accessory of a sensor. Switches receive
+// their button events from the button monitor, the other sensors their
+// state from the synchronizer.
+func configureSensor(device *device, synchronizer *Synchronizer, buttonMonitor *ButtonMonitor) *accessory.Accessory {
+	sensor := device.sensor
+
+	// switches emit button events instead of a state
+	if _, isSwitch := switchButtons[sensor.Type]; isSwitch {
+		sw := createProgrammableSwitchAccessory(device.id, sensor)
+
+		// receive button events from the bridge
+		buttonMonitor.Register(sensor, sw)
+
+		return sw.Accessory
+	}
+
+	// create the accessory based on the type
+	acc, updater := createSensorAccessory(device.id, sensor)
+
+	// set the initial state
+	if sensor.State != nil {
+		updater.UpdateSensor(sensor.State)
+	}
+
+	// receive state changes from the bridge
+	synchronizer.RegisterSensor(sensor.ID, updater)
+
+	return acc
+}
+
+func discoverGroups(selection []string, bridge hue.Bridger, ids *bridgeIDs) ([]*device, error) {
+	// initialize the devices
+	var devices []*device
+
+	// fetch all groups
+	groups, err := bridge.Groups()
+
+	// error handling
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch groups: %w", err)
+	}
+
+	// iterate through all hue groups
+	for _, group := range groups {
+		// check, if the group is selected by its id or name
+		if !selected(group.ID, group.Name, selection) {
 			continue
 		}
 
-		// create, configure and save the accessory
-		accessories = append(accessories, acc)
+		// allocate the accessory id
+		id, err := ids.group(group)
+
+		// error handling
+		if err != nil {
+			log.Errorf("cannot allocate an accessory id for group %s: %s", group.ID, err.Error())
+
+			continue
+		}
+
+		devices = append(devices, &device{
+			id:    id,
+			kind:  "group",
+			group: group,
+		})
+	}
+
+	// return all discovered devices
+	return devices, nil
+}
+
+// configureGroup Create the accessory of a room or zone, that receives
+// its state changes from the synchronizer
+func configureGroup(fades *Fades, device *device, bridge hue.Bridger, synchronizer *Synchronizer) *accessory.Accessory {
+	// fade the changes of all lights in the group
+	fade := newFadingBridge(bridge, fades.fade(nil, device.kind))
+
+	// create the accessory for the group
+	acc, updater := createGroupAccessory(device.id, device.group, instrument(fade, device.kind))
+
+	// allow homekit to set the fade of the next change
+	configureFade(acc, fade)
+
+	// receive state changes from the bridge
+	synchronizer.RegisterGroup(device.group.ID, updater)
+
+	return acc
+}
+
+func discoverScenes(selection []string, bridge hue.Bridger, ids *bridgeIDs) ([]*device, error) {
+	// initialize the devices
+	var devices []*device
+
+	// fetch all scenes
+	scenes, err := bridge.Scenes()
+
+	// error handling
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch scenes: %w", err)
+	}
+
+	// iterate through all hue scenes
+	for _, scene := range scenes {
+		// check, if the scene is selected by its id or name
+		if !selected(scene.ID, scene.Name, selection) {
+			continue
+		}
+
+		// allocate the accessory id
+		id, err := ids.scene(scene)
+
+		// error handling
+		if err != nil {
+			log.Errorf("cannot allocate an accessory id for scene %s: %s", scene.ID, err.Error())
+
+			continue
+		}
+
+		devices = append(devices, &device{
+			id:    id,
+			kind:  "scene",
+			scene: scene,
+		})
+	}
+
+	// return all discovered devices
+	return devices, nil
+}
+
+// selected Check if either the id or the name is contained in the
+// selection
+func selected(id, name string, selection []string) bool {
+	for _, entry := range selection {
+		if entry == id || entry == name {
+			return true
+		}
 	}
 
-	// return all configured accessories
-	return accessories
+	return false
 }
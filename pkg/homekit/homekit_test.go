@@ -0,0 +1,153 @@
+package homekit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/service"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestConfigureBridges(t *testing.T) {
+	var bridges []HueBridge
+
+	// two bridges with a light with the same id
+	for _, name := range []string{"Office", "Lab"} {
+		mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+			Lights: map[string]*hue.Light{
+				"1": {Type: "Dimmable light", Name: name, ModelID: "TRADFRI bulb E27 W opal 1000lm", State: &hue.State{}},
+			},
+		})
+		defer mockServer.Close()
+
+		simulator.AddUser("success")
+
+		bridge, err := hue.NewBridge(
+			strings.TrimPrefix(mockServer.URL, "https://"),
+			store.NewMemory(map[string]string{"bridge_username": "success"}),
+		)
+		assert.Nil(t, err)
+
+		bridges = append(bridges, HueBridge{Key: name, Bridge: bridge})
+	}
+
+	memory := store.NewMemory(nil)
+
+	discovered, err := discoverBridges(Config{}, bridges, NewIDAllocator(memory), nil)
+	assert.Nil(t, err)
+
+	result := configureBridges(Config{}, bridges, discovered)
+
+	assert.Len(t, result.synchronizers, 2)
+	assert.Len(t, result.buttonMonitors, 2)
+	assert.Len(t, result.lights, 2)
+
+	// the ids of the first bridge stay unchanged, the ids of the
+	// second bridge are allocated
+	var ids []uint64
+
+	for _, acc := range result.accessories {
+		ids = append(ids, acc.ID)
+	}
+
+	assert.Equal(t, []uint64{2, 3}, ids)
+
+	// the ids are persisted per bridge
+	office, _ := memory.Get("accessory_id/light/Office/1")
+	lab, _ := memory.Get("accessory_id/light/Lab/1")
+
+	assert.Equal(t, "2", office)
+	assert.Equal(t, "3", lab)
+
+	// a rule with bridge only excludes the light with the id of this
+	// bridge
+	filter, err := NewFilter(nil, []FilterRule{{Bridge: "lab", ID: "1"}})
+	assert.Nil(t, err)
+
+	discovered, err = discoverBridges(Config{Filter: filter}, bridges, NewIDAllocator(memory), nil)
+	assert.Nil(t, err)
+
+	bridged := map[string]bool{}
+
+	for _, status := range discovered.lights {
+		bridged[status.Light.Name] = status.Bridged
+	}
+
+	assert.Equal(t, map[string]bool{"Office": true, "Lab": false}, bridged)
+}
+
+func TestConfigureLights(t *testing.T) {
+	tests := []struct {
+		description             string
+		lightType               string
+		expectedCharacteristics []string
+	}{
+		{
+			description:             "on/off light",
+			lightType:               "On/Off light",
+			expectedCharacteristics: []string{characteristic.TypeOn},
+		},
+		{
+			description:             "color light without color temperature",
+			lightType:               "Color light",
+			expectedCharacteristics: []string{characteristic.TypeOn, characteristic.TypeBrightness, characteristic.TypeHue, characteristic.TypeSaturation, TypeFadeDuration},
+		},
+		{
+			description:             "color dimmable light",
+			lightType:               "Color dimmable light",
+			expectedCharacteristics: []string{characteristic.TypeOn, characteristic.TypeBrightness, characteristic.TypeHue, characteristic.TypeSaturation, TypeFadeDuration},
+		},
+		{
+			description:             "extended color light",
+			lightType:               "Extended color light",
+			expectedCharacteristics: []string{characteristic.TypeOn, characteristic.TypeBrightness, characteristic.TypeColorTemperature, characteristic.TypeHue, characteristic.TypeSaturation, TypeFadeDuration},
+		},
+	}
+
+	for _, test := range tests {
+		mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+			Lights: map[string]*hue.Light{
+				"1": {Type: test.lightType, Name: "Lamp", State: &hue.State{}},
+			},
+		})
+
+		simulator.AddUser("success")
+
+		bridge, err := hue.NewBridge(
+			strings.TrimPrefix(mockServer.URL, "https://"),
+			store.NewMemory(map[string]string{"bridge_username": "success"}),
+		)
+		assert.Nil(t, err)
+
+		bridges := []HueBridge{{Bridge: bridge}}
+		discovered, err := discoverBridges(Config{}, bridges, NewIDAllocator(store.NewMemory(nil)), nil)
+		assert.Nilf(t, err, test.description)
+
+		result := configureBridges(Config{}, bridges, discovered)
+
+		assert.Lenf(t, result.accessories, 1, test.description)
+		assert.Truef(t, result.lights[0].Bridged, test.description)
+
+		// collect the characteristics of the lightbulb service
+		var characteristics []string
+
+		for _, svc := range result.accessories[0].Services {
+			if svc.Type != service.TypeLightbulb {
+				continue
+			}
+
+			for _, c := range svc.Characteristics {
+				characteristics = append(characteristics, c.Type)
+			}
+		}
+
+		assert.Equalf(t, test.expectedCharacteristics, characteristics, test.description)
+
+		mockServer.Close()
+	}
+}
@@ -0,0 +1,383 @@
+package homekit
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+const (
+	// bridgeAccessoryID Id of the homekit bridge accessory itself
+	bridgeAccessoryID = 1
+
+	// accessoryIDPrefix Prefix of the keys, that map devices to their
+	// accessory id in the store
+	accessoryIDPrefix = "accessory_id/"
+
+	// accessoryKeyPrefix Prefix of the keys, that map accessory ids to
+	// their device in the store. They mark ids as used.
+	accessoryKeyPrefix = "accessory_key/"
+
+	// nextAccessoryIDKey Key of the next id to allocate in the store
+	nextAccessoryIDKey = "accessory_id_next"
+
+	// lightKeyPrefix Prefix of the keys, that save the key of the
+	// accessory id, that was chosen for a light
+	lightKeyPrefix = "light_key/"
+)
+
+// IDAllocator Assigns persistent accessory ids to devices. Every device
+// keeps its id forever and ids are never reused, such that homekit
+// rooms and automations survive deleted and re-paired devices.
+type IDAllocator struct {
+	store store.Store
+	mutex sync.Mutex
+}
+
+// NewIDAllocator Create a new allocator, that persists the ids in the
+// given store
+func NewIDAllocator(store store.Store) *IDAllocator {
+	return &IDAllocator{
+		store: store,
+	}
+}
+
+// ID Return the accessory id of the device with the given key. Unknown
+// devices get the preferred id, if it is still free, or the next free
+// id otherwise.
+func (a *IDAllocator) ID(key string, preferred uint64) (uint64, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// return the id of known devices
+	if value, err := a.store.Get(accessoryIDPrefix + key); err == nil {
+		return strconv.ParseUint(value, 10, 64)
+	}
+
+	id := preferred
+
+	// allocate the next free id, if the preferred one cannot be used
+	if id <= bridgeAccessoryID || a.used(id) {
+		next, err := a.next()
+
+		// error handling
+		if err != nil {
+			return 0, err
+		}
+
+		id = next
+	}
+
+	// mark the id as used before saving it for the device, such that
+	// it is never allocated twice
+	if err := a.store.Set(accessoryKeyPrefix+strconv.FormatUint(id, 10), key); err != nil {
+		return 0, err
+	}
+
+	return id, a.store.Set(accessoryIDPrefix+key, strconv.FormatUint(id, 10))
+}
+
+// next Return the next unused id and advance the counter. The mutex must
+// be held by the caller.
+func (a *IDAllocator) next() (uint64, error) {
+	next := uint64(bridgeAccessoryID + 1)
+
+	// continue with the saved counter
+	if value, err := a.store.Get(nextAccessoryIDKey); err == nil {
+		if next, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	// skip ids, that are already used, e.g. by preferred ids
+	for a.used(next) {
+		next++
+	}
+
+	return next, a.store.Set(nextAccessoryIDKey, strconv.FormatUint(next+1, 10))
+}
+
+// used Check, if the id was allocated before. The mutex must be held
+// by the caller.
+func (a *IDAllocator) used(id uint64) bool {
+	_, err := a.store.Get(accessoryKeyPrefix + strconv.FormatUint(id, 10))
+
+	return err == nil
+}
+
+// MigrateBridge Move the accessory ids and exclusions of the devices
+// without address from one bridge key to another, e.g. when the key of
+// a bridge changed from its address to its name. Devices, that already
+// have an id with the new key, keep it.
+func MigrateBridge(store store.Store, from, to string) error {
+	keys, err := store.Keys(accessoryIDPrefix)
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		// the keys of devices without address contain the bridge
+		// key after their kind, e.g. light/office/5
+		kind, rest, _ := strings.Cut(strings.TrimPrefix(key, accessoryIDPrefix), "/")
+
+		id, found := strings.CutPrefix(rest, from+"/")
+		if !found {
+			continue
+		}
+
+		if err := migrateID(store, key, kind+"/"+to+"/"+id); err != nil {
+			return err
+		}
+	}
+
+	if err := migrateLightKeys(store, from, to); err != nil {
+		return err
+	}
+
+	return migrateExclusions(store, from, to)
+}
+
+// migrateLightKeys Move the chosen keys of the lights from one bridge key
+// to another. Keys, that already exist with the new key, are kept.
+func migrateLightKeys(store store.Store, from, to string) error {
+	names, err := store.Keys(lightKeyPrefix + from + "/")
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		next := lightKeyPrefix + to + "/" + strings.TrimPrefix(name, lightKeyPrefix+from+"/")
+
+		if _, err := store.Get(next); err == nil {
+			continue
+		}
+
+		value, err := store.Get(name)
+
+		// error handling
+		if err != nil {
+			return err
+		}
+
+		var saved lightKey
+
+		if err := json.Unmarshal([]byte(value), &saved); err != nil {
+			return err
+		}
+
+		// lights without address contain the bridge key
+		if id, found := strings.CutPrefix(saved.Key, "light/"+from+"/"); found {
+			saved.Key = "light/" + to + "/" + id
+		}
+
+		bytes, err := json.Marshal(saved)
+
+		// error handling
+		if err != nil {
+			return err
+		}
+
+		if err := store.Set(next, string(bytes)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateID Assign the accessory id of the old key to the device with the
+// new key, unless the new key already has an id
+func migrateID(store store.Store, from, to string) error {
+	if _, err := store.Get(accessoryIDPrefix + to); err == nil {
+		return nil
+	}
+
+	value, err := store.Get(from)
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	// point the used id to the new key as well
+	if err := store.Set(accessoryKeyPrefix+value, to); err != nil {
+		return err
+	}
+
+	return store.Set(accessoryIDPrefix+to, value)
+}
+
+// bridgeIDs Allocates the accessory ids for the devices of one hue bridge
+type bridgeIDs struct {
+	allocator *IDAllocator
+
+	// namespace Distinguishes devices without unique id of multiple
+	// hue bridges
+	namespace string
+
+	// legacy Set for the first hue bridge, whose lights prefer the ids,
+	// that were computed from their ids before the ids were allocated
+	legacy bool
+}
+
+// lightKey Key of the accessory id, that was chosen for a light, and the
+// address of its device at that time
+type lightKey struct {
+	Address string `json:"address"`
+	Key     string `json:"key"`
+}
+
+// light Return the accessory id of a light. Lights are identified by the
+// mac address of their device, as the v1 api reports it with the
+// endpoint and the v2 api without. Lights of devices with multiple
+// lights are identified by their id, which is the same in both apis.
+// The choice is saved, such that a light keeps its id, when its device
+// gains or loses a light.
+func (b *bridgeIDs) light(light *hue.Light, shared bool) (uint64, error) {
+	var preferred uint64
+
+	// lights kept the id on the bridge plus one before
+	if number, err := strconv.ParseUint(light.ID, 10, 32); b.legacy && err == nil {
+		preferred = number + 1
+	}
+
+	key, err := b.lightKey(light, shared)
+
+	// error handling
+	if err != nil {
+		return 0, err
+	}
+
+	return b.allocator.ID(key, preferred)
+}
+
+// lightKey Return the saved key of the light or choose and save it. The
+// saved key is only used for the same device, as the id of a deleted
+// light can be reused by the bridge. Lights without unique id, e.g. of
+// devices with multiple lights on the v2 api, match every device.
+func (b *bridgeIDs) lightKey(light *hue.Light, shared bool) (string, error) {
+	address := deviceAddress(light.UniqueID)
+	name := lightKeyPrefix + b.key("", light.ID)
+
+	var saved lightKey
+
+	if value, err := b.allocator.store.Get(name); err == nil && json.Unmarshal([]byte(value), &saved) == nil {
+		if saved.Address == address || saved.Address == "" || address == "" {
+			return saved.Key, nil
+		}
+	}
+
+	chosen := lightKey{Address: address, Key: b.key("light", light.ID)}
+
+	if !shared && address != "" {
+		chosen.Key = "light/" + address
+	}
+
+	value, err := json.Marshal(chosen)
+
+	// error handling
+	if err != nil {
+		return "", err
+	}
+
+	return chosen.Key, b.allocator.store.Set(name, string(value))
+}
+
+// sensor Return the accessory id of a sensor or switch. Sensors are
+// identified by the mac address of their device and the kind of the
+// sensor, as a device can contain e.g. a motion and a light sensor.
+func (b *bridgeIDs) sensor(sensor *hue.Sensor) (uint64, error) {
+	address := deviceAddress(sensor.UniqueID)
+
+	if address != "" {
+		address += "-" + sensorKind(sensor.Type)
+	}
+
+	return b.id("sensor", address, sensor.ID, 0)
+}
+
+// group Return the accessory id of a room or zone
+func (b *bridgeIDs) group(group *hue.Group) (uint64, error) {
+	return b.id("group", "", group.ID, 0)
+}
+
+// scene Return the accessory id of a scene
+func (b *bridgeIDs) scene(scene *hue.Scene) (uint64, error) {
+	return b.id("scene", "", scene.ID, 0)
+}
+
+// id Allocate the id by the device address. Devices without address are
+// identified by their id on the bridge.
+func (b *bridgeIDs) id(kind, address, id string, preferred uint64) (uint64, error) {
+	// addresses are the same on every bridge, so devices keep their
+	// accessory id, when they are paired with another bridge
+	if address != "" {
+		return b.allocator.ID(kind+"/"+address, preferred)
+	}
+
+	return b.allocator.ID(b.key(kind, id), preferred)
+}
+
+// key Return the key of a device without address, which contains the
+// namespace of the bridge
+func (b *bridgeIDs) key(kind, id string) string {
+	parts := []string{}
+
+	for _, part := range []string{kind, b.namespace, id} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// deviceAddress Return the mac address of the device from the unique id
+// of a light or sensor, e.g. 00:17:88:01:02:03:04:05 for the v1 unique id
+// 00:17:88:01:02:03:04:05-0b
+func deviceAddress(uniqueID string) string {
+	address, _, _ := strings.Cut(uniqueID, "-")
+
+	return strings.ToLower(address)
+}
+
+// sensorKind Return the kind of the sensor type without the prefix of
+// the protocol, such that e.g. ZHAPresence and ZLLPresence are the same
+func sensorKind(sensorType string) string {
+	for _, prefix := range []string{"ZLL", "ZHA", "ZGP", "CLIP"} {
+		if kind, ok := strings.CutPrefix(sensorType, prefix); ok {
+			return strings.ToLower(kind)
+		}
+	}
+
+	return strings.ToLower(sensorType)
+}
+
+// sharedDevices Return the addresses of the devices with multiple lights
+func sharedDevices(lights []*hue.Light) map[string]bool {
+	counts := map[string]int{}
+
+	for _, light := range lights {
+		if address := deviceAddress(light.UniqueID); address != "" {
+			counts[address]++
+		}
+	}
+
+	shared := map[string]bool{}
+
+	for address, count := range counts {
+		if count > 1 {
+			shared[address] = true
+		}
+	}
+
+	return shared
+}
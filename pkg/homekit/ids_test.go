@@ -0,0 +1,271 @@
+package homekit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestIDAllocator_ID(t *testing.T) {
+	tests := []struct {
+		description string
+		data        map[string]string
+		key         string
+		preferred   uint64
+		expectedID  uint64
+	}{
+		{
+			description: "known device",
+			data: map[string]string{
+				"accessory_id/light/1": "42",
+			},
+			key:        "light/1",
+			preferred:  2,
+			expectedID: 42,
+		},
+		{
+			description: "free preferred id",
+			data:        map[string]string{},
+			key:         "light/1",
+			preferred:   2,
+			expectedID:  2,
+		},
+		{
+			description: "used preferred id",
+			data: map[string]string{
+				"accessory_key/2": "light/deleted",
+				"accessory_key/3": "light/3",
+			},
+			key:        "light/1",
+			preferred:  2,
+			expectedID: 4,
+		},
+		{
+			description: "id of the bridge",
+			data:        map[string]string{},
+			key:         "scene/abc",
+			preferred:   bridgeAccessoryID,
+			expectedID:  2,
+		},
+		{
+			description: "continue with the counter",
+			data: map[string]string{
+				"accessory_id_next": "10",
+			},
+			key:        "scene/abc",
+			preferred:  0,
+			expectedID: 10,
+		},
+	}
+
+	for _, test := range tests {
+		memory := store.NewMemory(test.data)
+
+		id, err := NewIDAllocator(memory).ID(test.key, test.preferred)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedID, id, test.description)
+	}
+}
+
+func TestBridgeIDs_Light(t *testing.T) {
+	memory := store.NewMemory(nil)
+
+	ids := &bridgeIDs{allocator: NewIDAllocator(memory), legacy: true}
+
+	first, err := ids.light(&hue.Light{ID: "1", UniqueID: "00:17:88:01:00:00:00:01-0b"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), first)
+
+	// the light was deleted and paired again with another id
+	repaired, err := ids.light(&hue.Light{ID: "7", UniqueID: "00:17:88:01:00:00:00:01-0b"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, first, repaired)
+
+	// the v2 api reports the unique id without the endpoint
+	v2, err := ids.light(&hue.Light{ID: "1", UniqueID: "00:17:88:01:00:00:00:01"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, first, v2)
+
+	// a new light got the id of the deleted light
+	other, err := ids.light(&hue.Light{ID: "1", UniqueID: "00:17:88:01:00:00:00:02-0b"}, false)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, other)
+
+	// lights of a device with multiple lights are identified by their
+	// id, as the v2 api reports no unique id for them
+	shared, err := ids.light(&hue.Light{ID: "4", UniqueID: "00:17:88:01:00:00:00:03-01"}, true)
+	assert.Nil(t, err)
+
+	v2, err = ids.light(&hue.Light{ID: "4"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, shared, v2)
+}
+
+func TestBridgeIDs_LightShared(t *testing.T) {
+	ids := &bridgeIDs{allocator: NewIDAllocator(store.NewMemory(nil)), namespace: "office"}
+
+	tests := []struct {
+		description string
+		light       *hue.Light
+		shared      bool
+		expectedID  uint64
+	}{
+		{
+			description: "single light of a device",
+			light:       &hue.Light{ID: "10", UniqueID: "00:17:88:01:00:00:00:0a-0b"},
+			shared:      false,
+			expectedID:  2,
+		},
+		{
+			description: "device gained a light",
+			light:       &hue.Light{ID: "10", UniqueID: "00:17:88:01:00:00:00:0a-0b"},
+			shared:      true,
+			expectedID:  2,
+		},
+		{
+			description: "gained light of the device",
+			light:       &hue.Light{ID: "11", UniqueID: "00:17:88:01:00:00:00:0a-0c"},
+			shared:      true,
+			expectedID:  3,
+		},
+		{
+			description: "device lost the light again",
+			light:       &hue.Light{ID: "10", UniqueID: "00:17:88:01:00:00:00:0a-0b"},
+			shared:      false,
+			expectedID:  2,
+		},
+		{
+			description: "lights of a device with multiple lights",
+			light:       &hue.Light{ID: "20", UniqueID: "00:17:88:01:00:00:00:14-0b"},
+			shared:      true,
+			expectedID:  4,
+		},
+		{
+			description: "device with multiple lights lost a light",
+			light:       &hue.Light{ID: "20", UniqueID: "00:17:88:01:00:00:00:14-0b"},
+			shared:      false,
+			expectedID:  4,
+		},
+		{
+			description: "deleted light, whose id was reused for another device",
+			light:       &hue.Light{ID: "20", UniqueID: "00:17:88:01:00:00:00:15-0b"},
+			shared:      false,
+			expectedID:  5,
+		},
+	}
+
+	for _, test := range tests {
+		id, err := ids.light(test.light, test.shared)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedID, id, test.description)
+	}
+}
+
+func TestBridgeIDs_Sensor(t *testing.T) {
+	ids := &bridgeIDs{allocator: NewIDAllocator(store.NewMemory(nil))}
+
+	v1, err := ids.sensor(&hue.Sensor{ID: "5", Type: hue.SensorTypeZHAPresence, UniqueID: "00:15:8d:00:01:02:03:04-01-0406"})
+	assert.Nil(t, err)
+
+	// the v2 api reports the v2 type in the unique id and the types of
+	// hue sensors
+	v2, err := ids.sensor(&hue.Sensor{ID: "5", Type: hue.SensorTypeZLLPresence, UniqueID: "00:15:8d:00:01:02:03:04-motion"})
+	assert.Nil(t, err)
+	assert.Equal(t, v1, v2)
+
+	// other sensors of the same device get another id
+	temperature, err := ids.sensor(&hue.Sensor{ID: "6", Type: hue.SensorTypeZHATemperature, UniqueID: "00:15:8d:00:01:02:03:04-01-0402"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, v1, temperature)
+}
+
+func TestSharedDevices(t *testing.T) {
+	shared := sharedDevices([]*hue.Light{
+		{ID: "1", UniqueID: "00:17:88:01:00:00:00:01-0b"},
+		{ID: "2", UniqueID: "00:17:88:01:00:00:00:02-01"},
+		{ID: "3", UniqueID: "00:17:88:01:00:00:00:02-02"},
+		{ID: "4"},
+	})
+
+	assert.Equal(t, map[string]bool{"00:17:88:01:00:00:00:02": true}, shared)
+}
+
+func TestMigrateBridge(t *testing.T) {
+	memory := store.NewMemory(map[string]string{
+		"accessory_id/light/192.168.1.2/4": "7",
+		"accessory_key/7":                  "light/192.168.1.2/4",
+		"accessory_id/group/192.168.1.2/1": "8",
+		"accessory_key/8":                  "group/192.168.1.2/1",
+		"accessory_id/group/office/1":      "9",
+		"accessory_key/9":                  "group/office/1",
+		"accessory_id/light/192.168.1.3/4": "11",
+		"excluded_light/192.168.1.2/4":     "true",
+		"excluded_light/192.168.1.2/5":     "false",
+		"excluded_light/office/5":          "true",
+		"accessory_key/11":                 "light/192.168.1.3/4",
+		"light_key/192.168.1.2/4":          `{"address":"00:17:88:01:00:00:00:04","key":"light/192.168.1.2/4"}`,
+	})
+
+	assert.Nil(t, MigrateBridge(memory, "192.168.1.2", "office"))
+
+	tests := []struct {
+		description   string
+		key           string
+		expectedValue string
+	}{
+		{
+			description:   "accessory id of a device without address",
+			key:           "accessory_id/light/office/4",
+			expectedValue: "7",
+		},
+		{
+			description:   "used id points to the new key",
+			key:           "accessory_key/7",
+			expectedValue: "light/office/4",
+		},
+		{
+			description:   "existing accessory id of the new key is kept",
+			key:           "accessory_id/group/office/1",
+			expectedValue: "9",
+		},
+		{
+			description:   "ids of other bridges are not moved",
+			key:           "accessory_id/light/192.168.1.3/4",
+			expectedValue: "11",
+		},
+		{
+			description:   "chosen key of a light",
+			key:           "light_key/office/4",
+			expectedValue: `{"address":"00:17:88:01:00:00:00:04","key":"light/office/4"}`,
+		},
+		{
+			description:   "exclusion",
+			key:           "excluded_light/office/4",
+			expectedValue: "true",
+		},
+		{
+			description:   "existing exclusion of the new key is kept",
+			key:           "excluded_light/office/5",
+			expectedValue: "true",
+		},
+	}
+
+	for _, test := range tests {
+		value, err := memory.Get(test.key)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedValue, value, test.description)
+	}
+
+	// the allocator returns the moved id
+	ids := &bridgeIDs{allocator: NewIDAllocator(memory), namespace: "office"}
+
+	id, err := ids.light(&hue.Light{ID: "4", UniqueID: "00:17:88:01:00:00:00:04-0b"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), id)
+}
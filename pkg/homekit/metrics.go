@@ -0,0 +1,141 @@
+package homekit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+var (
+	// lightsTotal Number of bridged and skipped lights
+	lightsTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "huekit_lights",
+		Help: "Number of lights, that are bridged to homekit or skipped.",
+	}, []string{"bridge", "decision"})
+
+	// lightReachable Reachability of the lights as reported by the
+	// bridge
+	lightReachable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "huekit_light_reachable",
+		Help: "1, if the hue bridge can reach the light, 0 otherwise.",
+	}, []string{"bridge", "id", "name"})
+
+	// characteristicReadsTotal Number of characteristic reads from
+	// homekit
+	characteristicReadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "huekit_homekit_characteristic_reads_total",
+		Help: "Number of characteristics, that homekit read, by accessory type.",
+	}, []string{"accessory"})
+
+	// characteristicWritesTotal Number of characteristic writes from
+	// homekit
+	characteristicWritesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "huekit_homekit_characteristic_writes_total",
+		Help: "Number of characteristics, that homekit wrote, by accessory type.",
+	}, []string{"accessory"})
+)
+
+// instrumentedBridge Count the characteristic reads and writes of an
+// accessory. Every characteristic of an accessory queries or updates
+// the bridge exactly once, when homekit reads or writes it.
+type instrumentedBridge struct {
+	hue.Bridger
+
+	reads  prometheus.Counter
+	writes prometheus.Counter
+}
+
+// instrument Wrap the bridge, such that the reads and writes are counted
+// for the given accessory type
+func instrument(bridge hue.Bridger, accessoryType string) hue.Bridger {
+	return &instrumentedBridge{
+		Bridger: bridge,
+		reads:   characteristicReadsTotal.WithLabelValues(accessoryType),
+		writes:  characteristicWritesTotal.WithLabelValues(accessoryType),
+	}
+}
+
+// Light Count the read and query the light from the bridge
+func (b *instrumentedBridge) Light(id string) (*hue.Light, error) {
+	b.reads.Inc()
+
+	return b.Bridger.Light(id)
+}
+
+// LightUpdateState Count the write and update the light
+func (b *instrumentedBridge) LightUpdateState(light *hue.Light, state *hue.State) error {
+	b.writes.Inc()
+
+	return b.Bridger.LightUpdateState(light, state)
+}
+
+// Group Count the read and query the group from the bridge
+func (b *instrumentedBridge) Group(id string) (*hue.Group, error) {
+	b.reads.Inc()
+
+	return b.Bridger.Group(id)
+}
+
+// GroupUpdateAction Count the write and update the group
+func (b *instrumentedBridge) GroupUpdateAction(group *hue.Group, action *hue.State) error {
+	b.writes.Inc()
+
+	return b.Bridger.GroupUpdateAction(group, action)
+}
+
+// RecallScene Count the write and recall the scene
+func (b *instrumentedBridge) RecallScene(scene *hue.Scene) error {
+	b.writes.Inc()
+
+	return b.Bridger.RecallScene(scene)
+}
+
+// recordLights Export the number of bridged and skipped lights of the
+// publication
+func recordLights(bridges []HueBridge, statuses []*LightStatus) {
+	lightsTotal.Reset()
+
+	// export both decisions for every bridge, even without lights
+	for _, bridge := range bridges {
+		lightsTotal.WithLabelValues(bridge.Key, "bridged").Set(0)
+		lightsTotal.WithLabelValues(bridge.Key, "skipped").Set(0)
+	}
+
+	for _, status := range statuses {
+		decision := "skipped"
+
+		if status.Bridged {
+			decision = "bridged"
+		}
+
+		lightsTotal.WithLabelValues(status.Bridge, decision).Inc()
+	}
+}
+
+// recordReachability Export the reachability of the lights of a bridge
+func recordReachability(bridge string, lights []*hue.Light) {
+	// forget deleted lights
+	lightReachable.DeletePartialMatch(prometheus.Labels{"bridge": bridge})
+
+	for _, light := range lights {
+		recordLightReachability(bridge, light)
+	}
+}
+
+// recordLightReachability Export the reachability of a single light, e.g.
+// from an event of the bridge
+func recordLightReachability(bridge string, light *hue.Light) {
+	// skip lights without state
+	if light.State == nil {
+		return
+	}
+
+	value := 0.0
+
+	if light.State.Reachable {
+		value = 1
+	}
+
+	lightReachable.WithLabelValues(bridge, light.ID, light.Name).Set(value)
+}
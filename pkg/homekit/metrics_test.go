@@ -0,0 +1,84 @@
+package homekit
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestInstrument(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Dimmable light", Name: "Desk", State: &hue.State{}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	reads := characteristicReadsTotal.WithLabelValues("metrics test")
+	writes := characteristicWritesTotal.WithLabelValues("metrics test")
+
+	_, updater := createDimmableLightAccessory(2, &hue.Light{ID: "1", Name: "Desk"}, instrument(bridge, "metrics test"))
+	light := updater.(*DimmableLightbulb)
+
+	// the connection of the iOS device, that changes the light
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	// homekit turns the light on and reads two characteristics
+	light.Lightbulb.On.UpdateValueFromConnection(true, conn)
+	light.Lightbulb.On.GetValueFromConnection(conn)
+	light.Lightbulb.Brightness.GetValueFromConnection(conn)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(reads))
+	assert.Equal(t, 1.0, testutil.ToFloat64(writes))
+	assert.True(t, simulator.Light("1").State.On)
+}
+
+func TestRecordLights(t *testing.T) {
+	recordLights(
+		[]HueBridge{{Key: "office"}, {Key: "attic"}},
+		[]*LightStatus{
+			{Bridge: "office", Light: &hue.Light{ID: "1"}, Bridged: true},
+			{Bridge: "office", Light: &hue.Light{ID: "2"}, Bridged: true},
+			{Bridge: "office", Light: &hue.Light{ID: "3"}, Bridged: false},
+		},
+	)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(lightsTotal.WithLabelValues("office", "bridged")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(lightsTotal.WithLabelValues("office", "skipped")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(lightsTotal.WithLabelValues("attic", "bridged")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(lightsTotal.WithLabelValues("attic", "skipped")))
+}
+
+func TestRecordReachability(t *testing.T) {
+	recordReachability("hallway", []*hue.Light{
+		{ID: "1", Name: "Lamp", State: &hue.State{Reachable: true}},
+		{ID: "2", Name: "Spot", State: &hue.State{Reachable: false}},
+	})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(lightReachable.WithLabelValues("hallway", "1", "Lamp")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(lightReachable.WithLabelValues("hallway", "2", "Spot")))
+
+	// deleted lights are removed
+	recordReachability("hallway", []*hue.Light{
+		{ID: "1", Name: "Lamp", State: &hue.State{Reachable: true}},
+	})
+
+	assert.Equal(t, 1, testutil.CollectAndCount(lightReachable))
+}
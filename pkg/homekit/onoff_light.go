@@ -0,0 +1,33 @@
+package homekit
+
+import (
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+func createOnOffLightAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating on/off light accessory for: %s - %s", light.ID, light.Name)
+
+	// create the lightbulb accessory, that can only be switched
+	ac := accessory.NewLightbulb(accessory.Info{
+		ID:               id,
+		Name:             light.Name,
+		Model:            light.ModelID,
+		Manufacturer:     light.ManufacturerName,
+		FirmwareRevision: light.SoftwareVersion,
+	})
+
+	// switch the light and fetch its power state
+	configurePowerState(id, light, bridge, ac.Lightbulb.On)
+
+	// update the power state, when the synchronizer detects
+	// a change
+	updater := StateUpdaterFunc(func(state *hue.State) {
+		ac.Lightbulb.On.SetValue(state.On)
+	})
+
+	// return the configured accessory
+	return ac.Accessory, updater
+}
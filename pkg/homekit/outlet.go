@@ -0,0 +1,47 @@
+package homekit
+
+import (
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+func createOutletAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating outlet accessory for: %s - %s", light.ID, light.Name)
+
+	// create the outlet accessory
+	ac := accessory.NewOutlet(accessory.Info{
+		ID:               id,
+		Name:             light.Name,
+		Model:            light.ModelID,
+		Manufacturer:     light.ManufacturerName,
+		FirmwareRevision: light.SoftwareVersion,
+	})
+
+	configurePowerState(id, light, bridge, ac.Outlet.On)
+
+	// the bridge cannot measure the load, so the outlet is in use,
+	// while it is switched on
+	ac.Outlet.OutletInUse.OnValueRemoteGet(func() bool {
+		// refetch the light information based on the id
+		l, err := bridge.Light(light.ID)
+
+		// error handling
+		if err != nil {
+			return false
+		}
+
+		return l.State.On
+	})
+
+	// update the power state, when the synchronizer detects
+	// a change
+	updater := StateUpdaterFunc(func(state *hue.State) {
+		ac.Outlet.On.SetValue(state.On)
+		ac.Outlet.OutletInUse.SetValue(state.On)
+	})
+
+	// return the configured accessory
+	return ac.Accessory, updater
+}
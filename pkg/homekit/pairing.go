@@ -0,0 +1,71 @@
+package homekit
+
+import (
+	"encoding/hex"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/db"
+	"github.com/brutella/hc/util"
+)
+
+const (
+	// bridgeUUIDKey Key of the id of the homekit bridge in the storage
+	// of hc
+	bridgeUUIDKey = "uuid"
+
+	// setupID Setup id of the homekit bridge. It is the default of hc.
+	setupID = "HOME"
+)
+
+// Controller An iOS device or home hub, that is paired with the homekit
+// bridge
+type Controller struct {
+	// ID Pairing id of the controller
+	ID string
+
+	// PublicKey Hex encoded long-term public key of the controller
+	PublicKey string
+}
+
+// SetupURI Return the X-HM uri, that is encoded in the setup qr code of
+// the homekit bridge with the given pin
+func SetupURI(pin string) (string, error) {
+	return util.XHMURI(pin, setupID, uint8(accessory.TypeBridge), []util.SetupFlag{util.SetupFlagIP})
+}
+
+// controllers Read the paired controllers from the storage of hc in the
+// given directory. The bridge itself is saved there as well, but it is
+// no controller.
+func controllers(storagePath string) ([]*Controller, error) {
+	storage, err := util.NewFileStorage(storagePath)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	// the id of the bridge is missing, before it was started once
+	uuid, _ := storage.Get(bridgeUUIDKey)
+
+	entities, err := db.NewDatabaseWithStorage(storage).Entities()
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Controller{}
+
+	for _, entity := range entities {
+		if entity.Name == string(uuid) {
+			continue
+		}
+
+		result = append(result, &Controller{
+			ID:        entity.Name,
+			PublicKey: hex.EncodeToString(entity.PublicKey),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package homekit
+
+import (
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/service"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// switchButtons Number of buttons of the supported switch types
+var switchButtons = map[string]int{
+	hue.SensorTypeZLLSwitch: 4,
+	hue.SensorTypeZGPSwitch: 4,
+	hue.SensorTypeZHASwitch: 4,
+}
+
+// ProgrammableSwitch Represent a switch or remote with one or more buttons
+type ProgrammableSwitch struct {
+	*accessory.Accessory
+	ServiceLabel *service.ServiceLabel
+	Buttons      []*ProgrammableSwitchButtonService
+}
+
+// NewProgrammableSwitch Create a new accessory for a switch with the
+// given number of buttons
+func NewProgrammableSwitch(info accessory.Info, buttons int) *ProgrammableSwitch {
+	// initialize the accessory
+	acc := ProgrammableSwitch{}
+
+	// set the base accessory with given information
+	acc.Accessory = accessory.New(info, accessory.TypeProgrammableSwitch)
+
+	// label the buttons with numbers
+	acc.ServiceLabel = service.NewServiceLabel()
+	acc.ServiceLabel.ServiceLabelNamespace.SetValue(characteristic.ServiceLabelNamespaceArabicNumerals)
+	acc.AddService(acc.ServiceLabel.Service)
+
+	// register one service per button
+	for i := 1; i <= buttons; i++ {
+		button := newProgrammableSwitchButtonService(i)
+
+		acc.Buttons = append(acc.Buttons, button)
+		acc.AddService(button.Service)
+	}
+
+	return &acc
+}
+
+// Press Emit a homekit event for the button, starting with 1
+func (acc *ProgrammableSwitch) Press(button int, event int) {
+	// ignore buttons, that the accessory does not have
+	if button < 1 || button > len(acc.Buttons) {
+		return
+	}
+
+	acc.Buttons[button-1].ProgrammableSwitchEvent.SetValue(event)
+}
+
+// ProgrammableSwitchButtonService Represent a single button of a switch
+type ProgrammableSwitchButtonService struct {
+	*service.StatelessProgrammableSwitch
+
+	ServiceLabelIndex *characteristic.ServiceLabelIndex
+}
+
+func newProgrammableSwitchButtonService(index int) *ProgrammableSwitchButtonService {
+	// instantiate the service
+	svc := ProgrammableSwitchButtonService{}
+	svc.StatelessProgrammableSwitch = service.NewStatelessProgrammableSwitch()
+
+	// register the index of the button
+	svc.ServiceLabelIndex = characteristic.NewServiceLabelIndex()
+	svc.ServiceLabelIndex.SetValue(index)
+	svc.AddCharacteristic(svc.ServiceLabelIndex.Characteristic)
+
+	// return the custom service
+	return &svc
+}
+
+func createProgrammableSwitchAccessory(id uint64, sensor *hue.Sensor) *ProgrammableSwitch {
+	log.Debugf("creating programmable switch accessory for: %s - %s", sensor.ID, sensor.Name)
+
+	// create the switch accessory
+	return NewProgrammableSwitch(accessory.Info{
+		ID:               id,
+		Name:             sensor.Name,
+		Model:            sensor.ModelID,
+		Manufacturer:     sensor.ManufacturerName,
+		FirmwareRevision: sensor.SoftwareVersion,
+	}, switchButtons[sensor.Type])
+}
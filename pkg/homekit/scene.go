@@ -0,0 +1,64 @@
+package homekit
+
+import (
+	"time"
+
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// sceneResetDelay Duration after which a scene switch turns itself off
+// again
+const sceneResetDelay = time.Second
+
+func createSceneAccessory(id uint64, scene *hue.Scene, bridge hue.Bridger) *accessory.Accessory {
+	log.Debugf("creating scene accessory for: %s - %s", scene.ID, scene.Name)
+
+	// create the switch accessory
+	ac := accessory.NewSwitch(accessory.Info{
+		ID:           id,
+		Name:         scene.Name,
+		Model:        scene.Type,
+		Manufacturer: "HueKit",
+	})
+
+	// configure what do to, when the home app turns on the scene
+	ac.Switch.On.OnValueRemoteUpdate(func(on bool) {
+		// scenes can only be activated, so nothing needs to be done,
+		// when the switch is turned off
+		if !on {
+			return
+		}
+
+		// recall the scene
+		err := bridge.RecallScene(scene)
+
+		log.WithFields(log.Fields{
+			"id":   id,
+			"name": scene.Name,
+			"type": scene.Type,
+		}).Debug("recall scene")
+
+		// if an error occurred...
+		if err != nil {
+			// ...log it
+			log.WithFields(log.Fields{
+				"id":    id,
+				"name":  scene.Name,
+				"scene": scene.ID,
+				"on":    "scene",
+			}).Errorf("%s", err.Error())
+		}
+
+		// the switch is stateless, so it is turned off again after
+		// a short delay
+		time.AfterFunc(sceneResetDelay, func() {
+			ac.Switch.On.SetValue(false)
+		})
+	})
+
+	// return the configured accessory
+	return ac.Accessory
+}
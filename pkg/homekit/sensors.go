@@ -0,0 +1,157 @@
+package homekit
+
+import (
+	"math"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/brutella/hc/characteristic"
+	"github.com/brutella/hc/service"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// sensorTypes Types of the sensors, that are published as homekit
+// sensors
+var sensorTypes = map[string]bool{
+	hue.SensorTypeZLLPresence:    true,
+	hue.SensorTypeZHAPresence:    true,
+	hue.SensorTypeZLLTemperature: true,
+	hue.SensorTypeZHATemperature: true,
+	hue.SensorTypeZLLLightLevel:  true,
+	hue.SensorTypeZHALightLevel:  true,
+	hue.SensorTypeZHAOpenClose:   true,
+	hue.SensorTypeZLLOpenClose:   true,
+}
+
+// MotionSensor Represent a presence sensor
+type MotionSensor struct {
+	*accessory.Accessory
+	MotionSensor *service.MotionSensor
+}
+
+// NewMotionSensor Create a new accessory for the presence sensor
+func NewMotionSensor(info accessory.Info) *MotionSensor {
+	acc := MotionSensor{}
+	acc.Accessory = accessory.New(info, accessory.TypeSensor)
+	acc.MotionSensor = service.NewMotionSensor()
+	acc.AddService(acc.MotionSensor.Service)
+
+	return &acc
+}
+
+// UpdateSensor Update the characteristics with the state of the sensor
+func (acc *MotionSensor) UpdateSensor(state *hue.SensorState) {
+	acc.MotionSensor.MotionDetected.SetValue(state.Presence)
+}
+
+// TemperatureSensor Represent a temperature sensor
+type TemperatureSensor struct {
+	*accessory.Accessory
+	TemperatureSensor *service.TemperatureSensor
+}
+
+// NewTemperatureSensor Create a new accessory for the temperature sensor
+func NewTemperatureSensor(info accessory.Info) *TemperatureSensor {
+	acc := TemperatureSensor{}
+	acc.Accessory = accessory.New(info, accessory.TypeSensor)
+	acc.TemperatureSensor = service.NewTemperatureSensor()
+
+	// allow temperatures below zero for outdoor sensors
+	acc.TemperatureSensor.CurrentTemperature.SetMinValue(-40)
+
+	acc.AddService(acc.TemperatureSensor.Service)
+
+	return &acc
+}
+
+// UpdateSensor Update the characteristics with the state of the sensor
+func (acc *TemperatureSensor) UpdateSensor(state *hue.SensorState) {
+	// hue reports the temperature in 0.01 °C
+	acc.TemperatureSensor.CurrentTemperature.SetValue(float64(state.Temperature) / 100)
+}
+
+// LightSensor Represent a light level sensor
+type LightSensor struct {
+	*accessory.Accessory
+	LightSensor *service.LightSensor
+}
+
+// NewLightSensor Create a new accessory for the light level sensor
+func NewLightSensor(info accessory.Info) *LightSensor {
+	acc := LightSensor{}
+	acc.Accessory = accessory.New(info, accessory.TypeSensor)
+	acc.LightSensor = service.NewLightSensor()
+	acc.AddService(acc.LightSensor.Service)
+
+	return &acc
+}
+
+// UpdateSensor Update the characteristics with the state of the sensor
+func (acc *LightSensor) UpdateSensor(state *hue.SensorState) {
+	acc.LightSensor.CurrentAmbientLightLevel.SetValue(lightLevelToLux(state.LightLevel))
+}
+
+// ContactSensor Represent an open/close sensor
+type ContactSensor struct {
+	*accessory.Accessory
+	ContactSensor *service.ContactSensor
+}
+
+// NewContactSensor Create a new accessory for the open/close sensor
+func NewContactSensor(info accessory.Info) *ContactSensor {
+	acc := ContactSensor{}
+	acc.Accessory = accessory.New(info, accessory.TypeSensor)
+	acc.ContactSensor = service.NewContactSensor()
+	acc.AddService(acc.ContactSensor.Service)
+
+	return &acc
+}
+
+// UpdateSensor Update the characteristics with the state of the sensor
+func (acc *ContactSensor) UpdateSensor(state *hue.SensorState) {
+	contact := characteristic.ContactSensorStateContactDetected
+
+	// an open door or window has no contact
+	if state.Open {
+		contact = characteristic.ContactSensorStateContactNotDetected
+	}
+
+	acc.ContactSensor.ContactSensorState.SetValue(contact)
+}
+
+// lightLevelToLux Convert the hue light level, which is
+// 10000 * log10(lux) + 1, into lux
+func lightLevelToLux(lightLevel int) float64 {
+	return math.Pow(10, float64(lightLevel-1)/10000)
+}
+
+func createSensorAccessory(id uint64, sensor *hue.Sensor) (*accessory.Accessory, SensorUpdater) {
+	log.Debugf("creating sensor accessory for: %s - %s", sensor.ID, sensor.Name)
+
+	info := accessory.Info{
+		ID:               id,
+		Name:             sensor.Name,
+		Model:            sensor.ModelID,
+		Manufacturer:     sensor.ManufacturerName,
+		FirmwareRevision: sensor.SoftwareVersion,
+	}
+
+	// create the accessory based on the type
+	switch sensor.Type {
+	case hue.SensorTypeZLLPresence, hue.SensorTypeZHAPresence:
+		acc := NewMotionSensor(info)
+		return acc.Accessory, acc
+	case hue.SensorTypeZLLTemperature, hue.SensorTypeZHATemperature:
+		acc := NewTemperatureSensor(info)
+		return acc.Accessory, acc
+	case hue.SensorTypeZLLLightLevel, hue.SensorTypeZHALightLevel:
+		acc := NewLightSensor(info)
+		return acc.Accessory, acc
+	case hue.SensorTypeZHAOpenClose, hue.SensorTypeZLLOpenClose:
+		acc := NewContactSensor(info)
+		return acc.Accessory, acc
+	}
+
+	return nil, nil
+}
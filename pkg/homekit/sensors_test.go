@@ -0,0 +1,82 @@
+package homekit
+
+import (
+	"testing"
+
+	"github.com/brutella/hc/characteristic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+func TestCreateSensorAccessory(t *testing.T) {
+	tests := []struct {
+		description   string
+		sensor        *hue.Sensor
+		expectedValue interface{}
+		value         func(SensorUpdater) interface{}
+	}{
+		{
+			description: "motion sensor",
+			sensor: &hue.Sensor{
+				ID:    "3",
+				Type:  hue.SensorTypeZHAPresence,
+				State: &hue.SensorState{Presence: true},
+			},
+			expectedValue: true,
+			value: func(u SensorUpdater) interface{} {
+				return u.(*MotionSensor).MotionSensor.MotionDetected.Value
+			},
+		},
+		{
+			description: "temperature sensor below zero",
+			sensor: &hue.Sensor{
+				ID:    "4",
+				Type:  hue.SensorTypeZHATemperature,
+				State: &hue.SensorState{Temperature: -550},
+			},
+			expectedValue: -5.5,
+			value: func(u SensorUpdater) interface{} {
+				return u.(*TemperatureSensor).TemperatureSensor.CurrentTemperature.Value
+			},
+		},
+		{
+			description: "light level sensor",
+			sensor: &hue.Sensor{
+				ID:    "5",
+				Type:  hue.SensorTypeZLLLightLevel,
+				State: &hue.SensorState{LightLevel: 20001},
+			},
+			expectedValue: 100.0,
+			value: func(u SensorUpdater) interface{} {
+				return u.(*LightSensor).LightSensor.CurrentAmbientLightLevel.Value
+			},
+		},
+		{
+			description: "open contact sensor",
+			sensor: &hue.Sensor{
+				ID:    "6",
+				Type:  hue.SensorTypeZHAOpenClose,
+				State: &hue.SensorState{Open: true},
+			},
+			expectedValue: characteristic.ContactSensorStateContactNotDetected,
+			value: func(u SensorUpdater) interface{} {
+				return u.(*ContactSensor).ContactSensor.ContactSensorState.Value
+			},
+		},
+	}
+
+	for _, test := range tests {
+		acc, updater := createSensorAccessory(2, test.sensor)
+
+		assert.NotNilf(t, acc, test.description)
+
+		updater.UpdateSensor(test.sensor.State)
+
+		assert.Equalf(t, test.expectedValue, test.value(updater), test.description)
+	}
+
+	// unsupported sensors must not create an accessory
+	acc, _ := createSensorAccessory(2, &hue.Sensor{ID: "1", Type: "Daylight"})
+	assert.Nil(t, acc)
+}
@@ -0,0 +1,462 @@
+package homekit
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brutella/hc"
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// BridgeName Name of the homekit bridge. The pairings with iOS devices
+// are saved in a directory with this name.
+const BridgeName = "HueKit Bridge"
+
+const (
+	// discoveryMinBackoff Delay before the initial discovery is
+	// retried for the first time
+	discoveryMinBackoff = 5 * time.Second
+
+	// discoveryMaxBackoff Maximum delay between the retries of the
+	// initial discovery
+	discoveryMaxBackoff = 5 * time.Minute
+)
+
+// Server Publishes the devices of the hue bridges in homekit. The devices
+// are discovered again on every reload, such that new, deleted and
+// renamed devices are picked up without a restart.
+type Server struct {
+	config     Config
+	bridges    []HueBridge
+	allocator  *IDAllocator
+	exclusions *Exclusions
+
+	// mutex Guards the current publication
+	mutex   sync.Mutex
+	current *publication
+
+	// contactMutex Guards the time of the last successful probe of
+	// every bridge
+	contactMutex sync.Mutex
+	contacts     map[string]time.Time
+
+	reload chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// publication The published accessories with the transport, that serves
+// them, and the background jobs, that keep their states up to date
+type publication struct {
+	transport      hc.Transport
+	accessories    []*accessory.Accessory
+	synchronizers  []*Synchronizer
+	buttonMonitors []*ButtonMonitor
+
+	// lights Decisions for all lights, including the skipped ones
+	lights []*LightStatus
+
+	// signature Description of the published devices, that is
+	// compared with the next discovery
+	signature string
+
+	// port The transport listens on. A random port is chosen, when
+	// the transport is created, such that it can be probed.
+	port string
+
+	// running Set, while the transport is started. The transport only
+	// serves homekit, once it listens on the port.
+	running atomic.Bool
+}
+
+// NewServer Create a new server for the hue bridges. The accessory ids
+// are persisted in the store.
+func NewServer(config Config, bridges []HueBridge, store store.Store) *Server {
+	// all bridges were reachable, when they were connected
+	contacts := map[string]time.Time{}
+
+	for _, bridge := range bridges {
+		contacts[bridge.Key] = time.Now()
+	}
+
+	return &Server{
+		contacts:   contacts,
+		config:     config,
+		bridges:    bridges,
+		allocator:  NewIDAllocator(store),
+		exclusions: NewExclusions(store),
+		reload:     make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run Publish the accessories and reload them in the configured interval
+// and on request, until Stop is called
+func (s *Server) Run() error {
+	defer close(s.done)
+
+	// publish the initial accessories, once all devices are
+	// discovered. Nothing is published before, as homekit would
+	// forget the rooms and automations of the missing devices.
+	discovered, ok := s.discoverInitial()
+	if !ok {
+		return nil
+	}
+
+	current := s.configure(discovered)
+
+	if err := current.start(s.config); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.current = current
+	s.mutex.Unlock()
+
+	// detect unreachable bridges
+	if s.config.UnreachableTimeout > 0 {
+		go s.probe()
+	}
+
+	// a disabled interval means, that only requested reloads are done
+	var tick <-chan time.Time
+
+	if s.config.ReloadInterval > 0 {
+		ticker := time.NewTicker(s.config.ReloadInterval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			s.current.stop()
+
+			return nil
+		case <-tick:
+			s.refresh()
+		case <-s.reload:
+			s.refresh()
+		}
+	}
+}
+
+// Reload Request a rediscovery of the devices. It returns immediately
+// and the reload is done in the background.
+func (s *Server) Reload() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+		// a reload is already pending
+	}
+}
+
+// Stop Unpublish the accessories and wait until Run returned
+func (s *Server) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// Lights Return the decisions for all lights of the last discovery.
+// It is empty, before the accessories were published.
+func (s *Server) Lights() []*LightStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.current == nil {
+		return nil
+	}
+
+	return s.current.lights
+}
+
+// Bridge Return the hue bridge with the given key
+func (s *Server) Bridge(key string) (hue.Bridger, bool) {
+	for _, bridge := range s.bridges {
+		if bridge.Key == key {
+			return bridge.Bridge, true
+		}
+	}
+
+	return nil, false
+}
+
+// Controllers Return the iOS devices and home hubs, that are paired with
+// the homekit bridge
+func (s *Server) Controllers() ([]*Controller, error) {
+	return controllers(BridgeName)
+}
+
+// Exclude Exclude the light of the bridge with the given key and
+// unpublish it with a reload
+func (s *Server) Exclude(bridge, id string) error {
+	if err := s.exclusions.Exclude(bridge, id); err != nil {
+		return err
+	}
+
+	s.Reload()
+
+	return nil
+}
+
+// Include Revert the exclusion of the light of the bridge with the given
+// key and publish it again with a reload
+func (s *Server) Include(bridge, id string) error {
+	if err := s.exclusions.Include(bridge, id); err != nil {
+		return err
+	}
+
+	s.Reload()
+
+	return nil
+}
+
+// discover Discover the devices of all hue bridges and export the
+// number of bridged and skipped lights
+func (s *Server) discover() (*discovery, error) {
+	result, err := discoverBridges(s.config, s.bridges, s.allocator, s.exclusions)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	recordLights(s.bridges, result.lights)
+
+	return result, nil
+}
+
+// discoverInitial Discover the devices until it succeeds. False is
+// returned, if the server was stopped before.
+func (s *Server) discoverInitial() (*discovery, bool) {
+	backoff := discoveryMinBackoff
+
+	for {
+		discovered, err := s.discover()
+
+		if err == nil {
+			return discovered, true
+		}
+
+		log.Errorf("cannot discover the devices, retrying in %s: %s", backoff, err.Error())
+
+		select {
+		case <-s.stop:
+			return nil, false
+		case <-time.After(backoff):
+		}
+
+		// increase the backoff for the next failed attempt
+		backoff *= 2
+		if backoff > discoveryMaxBackoff {
+			backoff = discoveryMaxBackoff
+		}
+	}
+}
+
+// configure Create the accessories of the discovered devices
+func (s *Server) configure(discovered *discovery) *publication {
+	return configureBridges(s.config, s.bridges, discovered)
+}
+
+// refresh Discover the devices again. If devices were added, removed or
+// renamed, the accessories are published again with a new configuration
+// number, such that paired iOS devices fetch them. Otherwise only the
+// firmware versions of the published accessories are updated. If the
+// discovery fails, the current accessories stay published.
+func (s *Server) refresh() {
+	discovered, err := s.discover()
+
+	// error handling
+	if err != nil {
+		log.Errorf("cannot discover the devices, keeping the published accessories: %s", err.Error())
+
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the decisions change without changed accessories, e.g. for
+	// renamed or skipped lights
+	s.current.lights = discovered.lights
+
+	// update the firmware in place, if the devices did not change
+	if s.current.signature == discovered.signature() {
+		updateFirmware(s.current.accessories, discovered.devices)
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"before": len(s.current.accessories),
+		"after":  len(discovered.devices),
+	}).Info("devices changed, publishing the accessories again")
+
+	next := s.configure(discovered)
+
+	// create the transport before stopping the current one, such
+	// that the current accessories stay published on errors
+	if err := next.create(s.config); err != nil {
+		log.Errorf("cannot publish the changed accessories: %s", err.Error())
+
+		return
+	}
+
+	s.current.stop()
+	next.run()
+
+	s.current = next
+}
+
+// create Create the transport for the accessories
+func (p *publication) create(config Config) error {
+	// create the bridge accessory. It is created again for every
+	// transport, as the transport registers itself at all accessories.
+	bridgeAccessory := accessory.NewBridge(accessory.Info{
+		ID:   bridgeAccessoryID,
+		Name: BridgeName,
+	})
+
+	// choose the random port before starting the transport, as hc
+	// does not report the port it listens on
+	port := config.Port
+
+	if port == "" {
+		free, err := freePort()
+
+		// error handling
+		if err != nil {
+			return err
+		}
+
+		port = free
+	}
+
+	// create the ip transport, that publishes homekit functionality
+	// and acts as the bridge
+	transport, err := hc.NewIPTransport(
+		hc.Config{Port: port, Pin: config.Pin, SetupId: setupID},
+		bridgeAccessory.Accessory,
+		p.accessories...,
+	)
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	p.transport = transport
+	p.port = port
+
+	return nil
+}
+
+// run Start the transport and the background jobs
+func (p *publication) run() {
+	// start pushing state changes and button events to homekit
+	for index := range p.synchronizers {
+		p.synchronizers[index].Start()
+		p.buttonMonitors[index].Start()
+	}
+
+	// start the communication
+	go func() {
+		p.running.Store(true)
+		defer p.running.Store(false)
+
+		p.transport.Start()
+	}()
+}
+
+// listening Check, if the transport was started and accepts connections
+// on its port
+func (p *publication) listening() bool {
+	if !p.running.Load() {
+		return false
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", p.port), time.Second)
+
+	// error handling
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+
+	return true
+}
+
+// start Create the transport and start the publication
+func (p *publication) start(config Config) error {
+	if err := p.create(config); err != nil {
+		return err
+	}
+
+	p.run()
+
+	return nil
+}
+
+// stop Stop the background jobs and the transport
+func (p *publication) stop() {
+	for index := range p.synchronizers {
+		p.synchronizers[index].Stop()
+		p.buttonMonitors[index].Stop()
+	}
+
+	<-p.transport.Stop()
+}
+
+// freePort Return a port, that is currently not used
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", ":0")
+
+	// error handling
+	if err != nil {
+		return "", err
+	}
+
+	defer listener.Close()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+
+	return port, err
+}
+
+// updateFirmware Copy the firmware versions of the discovered devices
+// into the published accessories with the same id
+func updateFirmware(published []*accessory.Accessory, discovered []*device) {
+	byID := map[uint64]*device{}
+
+	for _, device := range discovered {
+		byID[device.id] = device
+	}
+
+	for _, acc := range published {
+		next, ok := byID[acc.ID]
+		if !ok {
+			continue
+		}
+
+		_, firmware := next.info()
+
+		if firmware != acc.Info.FirmwareRevision.GetValue() {
+			log.Infof("accessory %d was updated to firmware %s", acc.ID, firmware)
+
+			acc.Info.FirmwareRevision.SetValue(firmware)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package homekit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestServer_Configure(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Dimmable light", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm", SoftwareVersion: "2.3.087", State: &hue.State{}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	server := NewServer(Config{}, []HueBridge{{Bridge: bridge}}, store.NewMemory(nil))
+
+	discovered, err := server.discover()
+	assert.Nil(t, err)
+
+	published := server.configure(discovered)
+
+	tests := []struct {
+		description      string
+		change           func()
+		expectedChanged  bool
+		expectedName     string
+		expectedFirmware string
+	}{
+		{
+			description:      "nothing changed",
+			change:           func() {},
+			expectedChanged:  false,
+			expectedName:     "Desk",
+			expectedFirmware: "2.3.087",
+		},
+		{
+			description: "firmware updated",
+			change: func() {
+				simulator.AddLight("1", &hue.Light{Type: "Dimmable light", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm", SoftwareVersion: "2.3.095", State: &hue.State{}})
+			},
+			expectedChanged:  false,
+			expectedName:     "Desk",
+			expectedFirmware: "2.3.095",
+		},
+		{
+			description: "light renamed",
+			change: func() {
+				simulator.AddLight("1", &hue.Light{Type: "Dimmable light", Name: "Office", ModelID: "TRADFRI bulb E27 W opal 1000lm", SoftwareVersion: "2.3.095", State: &hue.State{}})
+			},
+			expectedChanged:  true,
+			expectedName:     "Office",
+			expectedFirmware: "2.3.095",
+		},
+		{
+			description: "light added",
+			change: func() {
+				simulator.AddLight("2", &hue.Light{Type: "On/Off plug-in unit", Name: "Plug", ModelID: "Plug 01", State: &hue.State{}})
+			},
+			expectedChanged:  true,
+			expectedName:     "Office",
+			expectedFirmware: "2.3.095",
+		},
+		{
+			description: "light deleted",
+			change: func() {
+				simulator.DeleteLight("2")
+			},
+			expectedChanged:  true,
+			expectedName:     "Office",
+			expectedFirmware: "2.3.095",
+		},
+	}
+
+	for _, test := range tests {
+		test.change()
+
+		discovered, err := server.discover()
+		assert.Nilf(t, err, test.description)
+
+		changed := published.signature != discovered.signature()
+
+		assert.Equalf(t, test.expectedChanged, changed, test.description)
+
+		// only unchanged accessories are updated in place
+		if changed {
+			published = server.configure(discovered)
+		} else {
+			updateFirmware(published.accessories, discovered.devices)
+		}
+
+		assert.Equalf(t, test.expectedName, published.accessories[0].Info.Name.GetValue(), test.description)
+		assert.Equalf(t, test.expectedFirmware, published.accessories[0].Info.FirmwareRevision.GetValue(), test.description)
+	}
+}
+
+func TestServer_Refresh(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Dimmable light", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm", State: &hue.State{}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	unreliable := &unreliableBridge{Bridger: bridge}
+	server := NewServer(Config{}, []HueBridge{{Bridge: unreliable}}, store.NewMemory(nil))
+
+	discovered, err := server.discover()
+	assert.Nil(t, err)
+
+	published := server.configure(discovered)
+	server.current = published
+
+	// an updated light and a skipped genuine hue light do not change
+	// the accessories
+	simulator.AddLight("1", &hue.Light{Type: "Dimmable light", Name: "Desk", ModelID: "TRADFRI bulb E27 W opal 1000lm", SoftwareVersion: "2.3.095", State: &hue.State{}})
+	simulator.AddLight("2", &hue.Light{Type: "Extended color light", Name: "Ceiling", ManufacturerName: "Signify Netherlands B.V.", State: &hue.State{}})
+
+	server.refresh()
+
+	assert.Same(t, published, server.current)
+	assert.Equal(t, "2.3.095", published.accessories[0].Info.FirmwareRevision.GetValue())
+
+	// the decisions are up to date nevertheless
+	names := map[string]bool{}
+
+	for _, status := range server.Lights() {
+		names[status.Light.Name] = status.Bridged
+	}
+
+	assert.Equal(t, map[string]bool{"Desk": true, "Ceiling": false}, names)
+
+	// an unreachable bridge keeps the accessories published
+	unreliable.fail = true
+
+	server.refresh()
+
+	assert.Same(t, published, server.current)
+	assert.Len(t, published.accessories, 1)
+	assert.Len(t, server.Lights(), 2)
+}
+
+// unreliableBridge Fail to fetch the lights, once fail is set
+type unreliableBridge struct {
+	hue.Bridger
+
+	fail bool
+}
+
+func (b *unreliableBridge) Lights() ([]*hue.Light, error) {
+	if b.fail {
+		return nil, errors.New("bridge is unreachable")
+	}
+
+	return b.Bridger.Lights()
+}
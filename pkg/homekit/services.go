@@ -0,0 +1,130 @@
+package homekit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brutella/hc/characteristic"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// Services, that a light can be published as in homekit
+const (
+	// ServiceLightbulb Publish the light with the characteristics of
+	// its type
+	ServiceLightbulb = "lightbulb"
+
+	// ServiceOutlet Publish the light as outlet, that can only be
+	// switched on and off
+	ServiceOutlet = "outlet"
+
+	// ServiceSwitch Publish the light as switch, that can only be
+	// switched on and off
+	ServiceSwitch = "switch"
+
+	// ServiceFan Publish the light as fan, that can only be switched
+	// on and off
+	ServiceFan = "fan"
+)
+
+// ServiceOverrides Homekit services of lights, that should not be
+// published with the default service of their type. The overrides are
+// keyed by the unique id of the light, the mac address of its device or
+// by <bridge>/<id>, as the ids are only unique per bridge. A single
+// bridge without name uses the plain id.
+type ServiceOverrides map[string]string
+
+// NewServiceOverrides Create the overrides from the configured services
+// of the lights. An error is returned for unknown services.
+func NewServiceOverrides(services map[string]string) (ServiceOverrides, error) {
+	overrides := ServiceOverrides{}
+
+	for key, service := range services {
+		service = strings.ToLower(service)
+
+		switch service {
+		case ServiceLightbulb, ServiceOutlet, ServiceSwitch, ServiceFan:
+		default:
+			return nil, fmt.Errorf("unknown service '%s' for light '%s'", service, key)
+		}
+
+		// unique ids are compared case-insensitive
+		overrides[strings.ToLower(key)] = service
+	}
+
+	return overrides, nil
+}
+
+// service Return the service, that the light of the bridge with the
+// given key should be published as. The unique id is preferred over the
+// mac address, that both apis report, and the id. An empty string is
+// returned, if the service is not overridden.
+func (o ServiceOverrides) service(bridge string, light *hue.Light) string {
+	if light.UniqueID != "" {
+		if service, ok := o[strings.ToLower(light.UniqueID)]; ok {
+			return service
+		}
+
+		if service, ok := o[deviceAddress(light.UniqueID)]; ok {
+			return service
+		}
+	}
+
+	return o[serviceKey(bridge, light.ID)]
+}
+
+// serviceKey Return the key of the light with the given id on the bridge
+// with the given key
+func serviceKey(bridge, id string) string {
+	if bridge == "" {
+		return id
+	}
+
+	return strings.ToLower(bridge) + "/" + id
+}
+
+// configurePowerState Switch the light, when homekit changes the power
+// state, and fetch the power state for homekit from the bridge
+func configurePowerState(id uint64, light *hue.Light, bridge hue.Bridger, on *characteristic.On) {
+	// configure what do to, when the home app changes the state
+	// of the light
+	on.OnValueRemoteUpdate(func(value bool) {
+		// send a toggle request
+		err := bridge.LightUpdateState(light, &hue.State{On: value})
+
+		log.WithFields(log.Fields{
+			"id":   id,
+			"name": light.Name,
+			"type": light.Type,
+		}).Debugf("trigger state: %t", value)
+
+		// if an error occurred...
+		if err != nil {
+			// ...log it
+			log.WithFields(log.Fields{
+				"id":    id,
+				"name":  light.Name,
+				"state": value,
+				"on":    "on",
+			}).Errorf("%s", err.Error())
+		}
+	})
+
+	// configure what to do, when the home app fetches the state
+	// of the light
+	on.OnValueRemoteGet(func() bool {
+		// refetch the light information based on the id
+		l, err := bridge.Light(light.ID)
+
+		// return, that the light is of, if an error
+		// occurred
+		if err != nil {
+			return false
+		}
+
+		// otherwise return the correct state
+		return l.State.On
+	})
+}
@@ -0,0 +1,166 @@
+package homekit
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/brutella/hc/accessory"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestNewServiceOverrides(t *testing.T) {
+	tests := []struct {
+		description    string
+		services       map[string]string
+		expectedResult ServiceOverrides
+		expectedError  bool
+	}{
+		{
+			description:    "no overrides",
+			services:       map[string]string{},
+			expectedResult: ServiceOverrides{},
+		},
+		{
+			description: "id and unique id",
+			services: map[string]string{
+				"5":                          "Fan",
+				"00:17:88:01:02:03:04:AB-0b": "lightbulb",
+			},
+			expectedResult: ServiceOverrides{
+				"5":                          ServiceFan,
+				"00:17:88:01:02:03:04:ab-0b": ServiceLightbulb,
+			},
+		},
+		{
+			description:   "unknown service",
+			services:      map[string]string{"5": "thermostat"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := NewServiceOverrides(test.services)
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+		assert.Equalf(t, test.expectedResult, result, test.description)
+	}
+}
+
+func TestChooseLightAccessory(t *testing.T) {
+	overrides := ServiceOverrides{
+		"2":                          ServiceLightbulb,
+		"attic/3":                    ServiceSwitch,
+		"00:17:88:01:02:03:04:05-0b": ServiceFan,
+		"00:17:88:01:02:03:04:06":    ServiceSwitch,
+	}
+
+	tests := []struct {
+		description           string
+		bridge                string
+		light                 *hue.Light
+		expectedAccessoryType string
+	}{
+		{
+			description:           "plugs are outlets by default",
+			light:                 &hue.Light{ID: "1", Type: "On/Off plug-in unit"},
+			expectedAccessoryType: "outlet",
+		},
+		{
+			description:           "plug for a lamp",
+			light:                 &hue.Light{ID: "2", Type: "On/Off plug-in unit"},
+			expectedAccessoryType: "on/off light",
+		},
+		{
+			description:           "dimmable light as switch",
+			bridge:                "Attic",
+			light:                 &hue.Light{ID: "3", Type: "Dimmable light"},
+			expectedAccessoryType: "switch",
+		},
+		{
+			description:           "same id on another bridge",
+			bridge:                "Office",
+			light:                 &hue.Light{ID: "3", Type: "Dimmable light"},
+			expectedAccessoryType: "dimmable light",
+		},
+		{
+			description:           "plain id with multiple bridges",
+			bridge:                "Office",
+			light:                 &hue.Light{ID: "2", Type: "On/Off plug-in unit"},
+			expectedAccessoryType: "outlet",
+		},
+		{
+			description:           "override by the unique id",
+			bridge:                "Office",
+			light:                 &hue.Light{ID: "4", UniqueID: "00:17:88:01:02:03:04:05-0b", Type: "On/Off plug-in unit"},
+			expectedAccessoryType: "fan",
+		},
+		{
+			description:           "override by the mac address of the v1 unique id",
+			light:                 &hue.Light{ID: "6", UniqueID: "00:17:88:01:02:03:04:06-0b", Type: "Dimmable light"},
+			expectedAccessoryType: "switch",
+		},
+		{
+			description:           "override by the mac address of the v2 api",
+			light:                 &hue.Light{ID: "6", UniqueID: "00:17:88:01:02:03:04:06", Type: "Dimmable light"},
+			expectedAccessoryType: "switch",
+		},
+		{
+			description:           "lightbulb override keeps the type",
+			light:                 &hue.Light{ID: "2", Type: "Extended color light"},
+			expectedAccessoryType: "extended color light",
+		},
+		{
+			description:           "unsupported type",
+			light:                 &hue.Light{ID: "5", Type: "Window covering device"},
+			expectedAccessoryType: "",
+		},
+	}
+
+	for _, test := range tests {
+		create, accessoryType := chooseLightAccessory(test.light, overrides.service(test.bridge, test.light))
+
+		assert.Equalf(t, test.expectedAccessoryType, accessoryType, test.description)
+		assert.Equalf(t, test.expectedAccessoryType != "", create != nil, test.description)
+	}
+}
+
+func TestCreateOutletAccessory(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "On/Off plug-in unit", Name: "Heater", State: &hue.State{}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	acc, updater := createOutletAccessory(2, &hue.Light{ID: "1", Name: "Heater"}, bridge)
+
+	assert.Equal(t, accessory.TypeOutlet, acc.Type)
+
+	// the connection of the iOS device, that switches the outlet
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+
+	acc.Services[1].Characteristics[0].UpdateValueFromConnection(true, conn)
+
+	assert.True(t, simulator.Light("1").State.On)
+	assert.Equal(t, true, acc.Services[1].Characteristics[1].GetValueFromConnection(conn))
+
+	// the outlet is not in use, after it was switched off
+	updater.UpdateState(&hue.State{On: false})
+
+	assert.Equal(t, false, acc.Services[1].Characteristics[1].Value)
+}
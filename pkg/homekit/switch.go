@@ -0,0 +1,32 @@
+package homekit
+
+import (
+	"github.com/brutella/hc/accessory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+func createSwitchAccessory(id uint64, light *hue.Light, bridge hue.Bridger) (*accessory.Accessory, StateUpdater) {
+	log.Debugf("creating switch accessory for: %s - %s", light.ID, light.Name)
+
+	// create the switch accessory
+	ac := accessory.NewSwitch(accessory.Info{
+		ID:               id,
+		Name:             light.Name,
+		Model:            light.ModelID,
+		Manufacturer:     light.ManufacturerName,
+		FirmwareRevision: light.SoftwareVersion,
+	})
+
+	configurePowerState(id, light, bridge, ac.Switch.On)
+
+	// update the power state, when the synchronizer detects
+	// a change
+	updater := StateUpdaterFunc(func(state *hue.State) {
+		ac.Switch.On.SetValue(state.On)
+	})
+
+	// return the configured accessory
+	return ac.Accessory, updater
+}
@@ -0,0 +1,348 @@
+package homekit
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// StateUpdater Update the characteristics of an accessory with the
+// state of a hue light
+type StateUpdater interface {
+	UpdateState(state *hue.State)
+}
+
+// StateUpdaterFunc Adapter, that allows using ordinary functions as
+// StateUpdater
+type StateUpdaterFunc func(state *hue.State)
+
+// UpdateState Call the function with the given state
+func (f StateUpdaterFunc) UpdateState(state *hue.State) {
+	f(state)
+}
+
+// SensorUpdater Update the characteristics of an accessory with the
+// state of a hue sensor
+type SensorUpdater interface {
+	UpdateSensor(state *hue.SensorState)
+}
+
+// Synchronizer Periodically fetches the state of all lights from the
+// bridge and pushes changes into the registered accessories, such that
+// homekit gets notified about changes made outside of homekit
+type Synchronizer struct {
+	key      string
+	bridge   hue.Bridger
+	interval time.Duration
+
+	mutex         sync.Mutex
+	updaters      map[string]StateUpdater
+	states        map[string]*hue.State
+	groupUpdaters map[string]StateUpdater
+	groupStates   map[string]*hue.State
+	sensors       map[string]SensorUpdater
+	sensorStates  map[string]*hue.SensorState
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSynchronizer Create a new synchronizer for the given bridge, that
+// fetches the light states in the given interval
+func NewSynchronizer(bridge HueBridge, interval time.Duration) *Synchronizer {
+	return &Synchronizer{
+		key:           bridge.Key,
+		bridge:        bridge.Bridge,
+		interval:      interval,
+		updaters:      map[string]StateUpdater{},
+		states:        map[string]*hue.State{},
+		groupUpdaters: map[string]StateUpdater{},
+		groupStates:   map[string]*hue.State{},
+		sensors:       map[string]SensorUpdater{},
+		sensorStates:  map[string]*hue.SensorState{},
+	}
+}
+
+// Register Add an accessory, that should receive the state updates of
+// the light with the given id
+func (s *Synchronizer) Register(id string, updater StateUpdater) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.updaters[id] = updater
+}
+
+// RegisterGroup Add an accessory, that should receive the state updates
+// of the group with the given id
+func (s *Synchronizer) RegisterGroup(id string, updater StateUpdater) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.groupUpdaters[id] = updater
+}
+
+// RegisterSensor Add an accessory, that should receive the state updates
+// of the sensor with the given id
+func (s *Synchronizer) RegisterSensor(id string, updater SensorUpdater) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sensors[id] = updater
+}
+
+// Sync Fetch all lights, groups and sensors once and update the
+// accessories, whose state changed since the last synchronization. A
+// failing resource type is logged and does not stop the others. The
+// mutex is only held to apply the fetched states, such that slow
+// requests do not block the events, the registration and Stop.
+func (s *Synchronizer) Sync() error {
+	// snapshot, which resources are published, before fetching them
+	s.mutex.Lock()
+	groups, sensors := len(s.groupUpdaters) > 0, len(s.sensors) > 0
+	s.mutex.Unlock()
+
+	var errs []error
+
+	for _, resource := range []struct {
+		name      string
+		published bool
+		run       func() error
+	}{
+		{"lights", true, s.syncLights},
+		{"groups", groups, s.syncGroups},
+		{"sensors", sensors, s.syncSensors},
+	} {
+		// only fetch the resources, that are published
+		if !resource.published {
+			continue
+		}
+
+		if err := resource.run(); err != nil {
+			log.WithFields(log.Fields{
+				"bridge": s.key,
+			}).Errorf("cannot synchronize the %s: %s", resource.name, err.Error())
+
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// syncLights Fetch all lights once and update the accessories of all
+// lights, whose state changed
+func (s *Synchronizer) syncLights() error {
+	// fetch all lights with a single request
+	lights, err := s.bridge.Lights()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	// export the reachability of all lights
+	recordReachability(s.key, lights)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// iterate through all lights
+	for _, light := range lights {
+		// skip lights without state
+		if light.State == nil {
+			continue
+		}
+
+		s.update(s.updaters, s.states, light.ID, light.State)
+	}
+
+	return nil
+}
+
+// syncGroups Fetch all groups once and update the accessories of the
+// published groups
+func (s *Synchronizer) syncGroups() error {
+	// fetch all groups with a single request
+	groups, err := s.bridge.Groups()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// iterate through all groups
+	for _, group := range groups {
+		s.update(s.groupUpdaters, s.groupStates, group.ID, groupState(group))
+	}
+
+	return nil
+}
+
+// syncSensors Fetch all sensors once and update the accessories of all
+// sensors, whose state changed
+func (s *Synchronizer) syncSensors() error {
+	// fetch all sensors with a single request
+	sensors, err := s.bridge.Sensors()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// iterate through all sensors
+	for _, sensor := range sensors {
+		s.updateSensor(sensor)
+	}
+
+	return nil
+}
+
+// updateSensor Push the state of the sensor to its accessory, if it
+// changed. The mutex must be held by the caller.
+func (s *Synchronizer) updateSensor(sensor *hue.Sensor) {
+	// skip sensors without state or accessory
+	updater, ok := s.sensors[sensor.ID]
+	if !ok || sensor.State == nil {
+		return
+	}
+
+	// skip the sensor, if its state did not change
+	if reflect.DeepEqual(s.sensorStates[sensor.ID], sensor.State) {
+		return
+	}
+
+	// save the new state and push it to homekit
+	s.sensorStates[sensor.ID] = sensor.State
+	updater.UpdateSensor(sensor.State)
+}
+
+// Handle Push the resource of an event, that was received from the
+// bridge, to its accessory
+func (s *Synchronizer) Handle(event *hue.Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case event.Light != nil && event.Light.State != nil:
+		// bridges with events are not polled, so the events are
+		// the only source of the reachability
+		recordLightReachability(s.key, event.Light)
+
+		s.update(s.updaters, s.states, event.Light.ID, event.Light.State)
+	case event.Group != nil:
+		s.update(s.groupUpdaters, s.groupStates, event.Group.ID, groupState(event.Group))
+	case event.Sensor != nil:
+		s.updateSensor(event.Sensor)
+	}
+}
+
+// update Push the state to the accessory with the given id, if it changed
+// since the last synchronization. The mutex must be held by the caller.
+func (s *Synchronizer) update(updaters map[string]StateUpdater, states map[string]*hue.State, id string, state *hue.State) {
+	// skip states without accessory
+	updater, ok := updaters[id]
+	if !ok {
+		return
+	}
+
+	// skip the state, if it did not change
+	if reflect.DeepEqual(states[id], state) {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"id": id,
+	}).Debug("state changed")
+
+	// save the new state and push it to homekit
+	states[id] = state
+	updater.UpdateState(state)
+}
+
+// Start Run the synchronization in the background until Stop is called.
+// Bridges, that push their state changes, are subscribed to. All other
+// bridges are polled in the configured interval.
+func (s *Synchronizer) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	// prefer the events of the bridge over polling
+	if subscriber, ok := s.bridge.(hue.Subscriber); ok {
+		events, err := subscriber.Subscribe(s.stop)
+
+		if err == nil {
+			// export the reachability once, as the events only
+			// contain the changed lights
+			if lights, err := s.bridge.Lights(); err == nil {
+				recordReachability(s.key, lights)
+			}
+
+			go s.consume(events)
+
+			return
+		}
+
+		// fall back to polling
+		if err != hue.ErrSubscriptionNotSupported {
+			log.Warnf("cannot subscribe to the bridge, polling instead: %s", err.Error())
+		}
+	}
+
+	// a disabled interval means, that no synchronization is wanted
+	if s.interval <= 0 {
+		s.stop = nil
+
+		return
+	}
+
+	go s.run()
+}
+
+// Stop Stop the background synchronization and wait until it finished
+func (s *Synchronizer) Stop() {
+	// return early, if the synchronization was never started
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Synchronizer) consume(events <-chan *hue.Event) {
+	defer close(s.done)
+
+	// the channel is closed by the bridge, once stop is closed
+	for event := range events {
+		s.Handle(event)
+	}
+}
+
+func (s *Synchronizer) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// synchronize the states. Errors are logged by
+			// Sync and the next tick will try it again.
+			_ = s.Sync()
+		}
+	}
+}
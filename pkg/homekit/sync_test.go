@@ -0,0 +1,285 @@
+package homekit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/hue/huetest"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestSynchronizer_Sync(t *testing.T) {
+	mockServer, simulator := huetest.NewServer(&huetest.Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {Type: "Dimmable light", Name: "Desk", State: &hue.State{}},
+			"2": {Type: "Color temperature light", Name: "Ceiling", State: &hue.State{}},
+		},
+	})
+	defer mockServer.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(mockServer.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	synchronizer := NewSynchronizer(HueBridge{Bridge: bridge}, 0)
+
+	_, dimmableUpdater := createDimmableLightAccessory(2, &hue.Light{ID: "1", Name: "Desk"}, bridge)
+	synchronizer.Register("1", dimmableUpdater)
+	dimmable := dimmableUpdater.(*DimmableLightbulb)
+
+	_, cctUpdater := createColorTemperatureLightAccessory(3, &hue.Light{ID: "2", Name: "Ceiling"}, bridge)
+	synchronizer.Register("2", cctUpdater)
+	cct := cctUpdater.(*ColorTemperatureLight)
+
+	tests := []struct {
+		description        string
+		on                 bool
+		bri                int
+		ct                 int
+		expectedOn         bool
+		expectedBrightness int
+		expectedCT         int
+	}{
+		{
+			description:        "lights turned on",
+			on:                 true,
+			bri:                254,
+			ct:                 366,
+			expectedOn:         true,
+			expectedBrightness: 100,
+			expectedCT:         366,
+		},
+		{
+			description:        "lights dimmed with a physical dimmer",
+			on:                 true,
+			bri:                127,
+			ct:                 200,
+			expectedOn:         true,
+			expectedBrightness: 50,
+			expectedCT:         200,
+		},
+		{
+			description:        "lights turned off in the hue app",
+			on:                 false,
+			bri:                127,
+			ct:                 200,
+			expectedOn:         false,
+			expectedBrightness: 50,
+			expectedCT:         200,
+		},
+	}
+
+	for _, test := range tests {
+		simulator.SetLightState("1", &hue.State{On: test.on, Brightness: test.bri})
+		simulator.SetLightState("2", &hue.State{On: test.on, Brightness: test.bri, ColorTemperature: test.ct})
+
+		err := synchronizer.Sync()
+		assert.Nilf(t, err, test.description)
+
+		assert.Equalf(t, test.expectedOn, dimmable.Lightbulb.On.Value, test.description)
+		assert.Equalf(t, test.expectedBrightness, dimmable.Lightbulb.Brightness.Value, test.description)
+
+		assert.Equalf(t, test.expectedOn, cct.Lightbulb.On.Value, test.description)
+		assert.Equalf(t, test.expectedBrightness, cct.Lightbulb.Brightness.Value, test.description)
+		assert.Equalf(t, test.expectedCT, cct.Lightbulb.ColorTemperature.Value, test.description)
+	}
+}
+
+func TestSynchronizer_Handle(t *testing.T) {
+	synchronizer := NewSynchronizer(HueBridge{}, 0)
+
+	var received []*hue.State
+
+	synchronizer.Register("1", StateUpdaterFunc(func(state *hue.State) {
+		received = append(received, state)
+	}))
+
+	tests := []struct {
+		description      string
+		event            *hue.Event
+		expectedReceived int
+	}{
+		{
+			description:      "light turned on",
+			event:            &hue.Event{Light: &hue.Light{ID: "1", State: &hue.State{On: true}}},
+			expectedReceived: 1,
+		},
+		{
+			description:      "unchanged state",
+			event:            &hue.Event{Light: &hue.Light{ID: "1", State: &hue.State{On: true}}},
+			expectedReceived: 1,
+		},
+		{
+			description:      "unknown light",
+			event:            &hue.Event{Light: &hue.Light{ID: "2", State: &hue.State{On: false}}},
+			expectedReceived: 1,
+		},
+		{
+			description:      "light turned off",
+			event:            &hue.Event{Light: &hue.Light{ID: "1", State: &hue.State{On: false}}},
+			expectedReceived: 2,
+		},
+	}
+
+	for _, test := range tests {
+		synchronizer.Handle(test.event)
+
+		assert.Lenf(t, received, test.expectedReceived, test.description)
+	}
+}
+
+// subscribingBridge Push the events of the channel like the event stream
+// of a v2 bridge
+type subscribingBridge struct {
+	hue.Bridger
+
+	lights []*hue.Light
+	events chan *hue.Event
+}
+
+func (b *subscribingBridge) Lights() ([]*hue.Light, error) {
+	return b.lights, nil
+}
+
+func (b *subscribingBridge) Subscribe(stop <-chan struct{}) (<-chan *hue.Event, error) {
+	go func() {
+		<-stop
+		close(b.events)
+	}()
+
+	return b.events, nil
+}
+
+func TestSynchronizer_Subscribe(t *testing.T) {
+	bridge := &subscribingBridge{
+		lights: []*hue.Light{
+			{ID: "1", Name: "Desk", State: &hue.State{Reachable: true}},
+			{ID: "2", Name: "Ceiling", State: &hue.State{Reachable: true}},
+		},
+		events: make(chan *hue.Event),
+	}
+
+	synchronizer := NewSynchronizer(HueBridge{Key: "v2", Bridge: bridge}, 0)
+	synchronizer.Start()
+
+	// the reachability is exported, when subscribing
+	assert.Equal(t, 1.0, testutil.ToFloat64(lightReachable.WithLabelValues("v2", "2", "Ceiling")))
+
+	// events update the reachability without polling
+	bridge.events <- &hue.Event{Light: &hue.Light{ID: "2", Name: "Ceiling", State: &hue.State{Reachable: false}}}
+
+	synchronizer.Stop()
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(lightReachable.WithLabelValues("v2", "2", "Ceiling")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(lightReachable.WithLabelValues("v2", "1", "Desk")))
+}
+
+// failingBridge Fail to fetch the groups, while the lights and sensors
+// can be fetched
+type failingBridge struct {
+	hue.Bridger
+}
+
+func (b *failingBridge) Lights() ([]*hue.Light, error) {
+	return []*hue.Light{{ID: "1", State: &hue.State{On: true}}}, nil
+}
+
+func (b *failingBridge) Groups() ([]*hue.Group, error) {
+	return nil, errors.New("groups are unavailable")
+}
+
+func (b *failingBridge) Sensors() ([]*hue.Sensor, error) {
+	return []*hue.Sensor{{ID: "5", State: &hue.SensorState{Presence: true}}}, nil
+}
+
+// sensorUpdaterFunc Adapter, that allows using ordinary functions as
+// SensorUpdater
+type sensorUpdaterFunc func(state *hue.SensorState)
+
+func (f sensorUpdaterFunc) UpdateSensor(state *hue.SensorState) {
+	f(state)
+}
+
+func TestSynchronizer_SyncFailure(t *testing.T) {
+	synchronizer := NewSynchronizer(HueBridge{Key: "office", Bridge: &failingBridge{}}, 0)
+
+	var light *hue.State
+	var sensor *hue.SensorState
+
+	synchronizer.Register("1", StateUpdaterFunc(func(state *hue.State) {
+		light = state
+	}))
+	synchronizer.RegisterGroup("1", StateUpdaterFunc(func(state *hue.State) {}))
+	synchronizer.RegisterSensor("5", sensorUpdaterFunc(func(state *hue.SensorState) {
+		sensor = state
+	}))
+
+	// the failing groups are reported, but do not stop the others
+	assert.EqualError(t, synchronizer.Sync(), "groups are unavailable")
+	assert.Equal(t, &hue.State{On: true}, light)
+	assert.Equal(t, &hue.SensorState{Presence: true}, sensor)
+}
+
+// blockingBridge Wait for the release before answering, like a slow bridge
+type blockingBridge struct {
+	hue.Bridger
+
+	fetching chan struct{}
+	release  chan struct{}
+}
+
+func (b *blockingBridge) Lights() ([]*hue.Light, error) {
+	close(b.fetching)
+	<-b.release
+
+	return []*hue.Light{{ID: "1", State: &hue.State{On: true}}}, nil
+}
+
+func TestSynchronizer_SyncWithoutLock(t *testing.T) {
+	bridge := &blockingBridge{fetching: make(chan struct{}), release: make(chan struct{})}
+	synchronizer := NewSynchronizer(HueBridge{Bridge: bridge}, 0)
+
+	done := make(chan error)
+
+	go func() {
+		done <- synchronizer.Sync()
+	}()
+
+	<-bridge.fetching
+
+	// accessories are registered and events are handled, while the
+	// bridge is queried
+	var light *hue.State
+
+	registered := make(chan struct{})
+
+	go func() {
+		synchronizer.Register("1", StateUpdaterFunc(func(state *hue.State) {
+			light = state
+		}))
+		synchronizer.Handle(&hue.Event{Group: &hue.Group{ID: "1"}})
+
+		close(registered)
+	}()
+
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("the synchronization blocks the registration")
+	}
+
+	close(bridge.release)
+
+	assert.Nil(t, <-done)
+	assert.Equal(t, &hue.State{On: true}, light)
+}
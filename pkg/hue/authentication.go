@@ -9,11 +9,59 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// authenticationAttempts Number of attempts, that are made in intervals
+// of one second, until the link button must be pressed
+const authenticationAttempts = 30
+
+// PendingAuthentication An authentication, that waits for the link button
+// of a bridge to be pressed
+type PendingAuthentication struct {
+	// Address Address of the bridge
+	Address string
+
+	// Deadline Time, at which the authentication fails, if the link
+	// button was not pressed
+	Deadline time.Time
+}
+
+// pendingAuthentications Authentications by the address of the bridge,
+// that currently wait for the link button
+var pendingAuthentications = struct {
+	sync.Mutex
+	deadlines map[string]time.Time
+}{
+	deadlines: map[string]time.Time{},
+}
+
+// PendingAuthentications Return all authentications, that currently
+// wait for the link button, sorted by the address of the bridge
+func PendingAuthentications() []*PendingAuthentication {
+	pendingAuthentications.Lock()
+	defer pendingAuthentications.Unlock()
+
+	result := []*PendingAuthentication{}
+
+	for address, deadline := range pendingAuthentications.deadlines {
+		result = append(result, &PendingAuthentication{
+			Address:  address,
+			Deadline: deadline,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+
+	return result
+}
+
 type authRequest struct {
 	DeviceType string `json:"devicetype"`
 }
@@ -49,7 +97,7 @@ func generateUsername() (string, error) {
 	return hex.EncodeToString(hashedSeed[:10]), nil
 }
 
-func authenticate(address string) (string, error) {
+func authenticate(client *http.Client, address string) (string, error) {
 	// generate a new username
 	id, err := generateUsername()
 
@@ -59,12 +107,23 @@ func authenticate(address string) (string, error) {
 
 	log.Info("Please press the link button on your bridge")
 
+	// publish the waiting authentication, e.g. for the dashboard
+	pendingAuthentications.Lock()
+	pendingAuthentications.deadlines[address] = time.Now().Add(authenticationAttempts * time.Second)
+	pendingAuthentications.Unlock()
+
+	defer func() {
+		pendingAuthentications.Lock()
+		delete(pendingAuthentications.deadlines, address)
+		pendingAuthentications.Unlock()
+	}()
+
 	// try 30 times to authenticate in intervals if 1 second.
 	// This needs to be performed, in order to check, if the
 	// link button was pressed
-	for i := 0; i < 30; i++ {
+	for i := 0; i < authenticationAttempts; i++ {
 		// try to authenticate
-		username, err := performAuthRequest(address, id)
+		username, err := performAuthRequest(client, address, id)
 
 		// debug log
 		log.Debugf("%v", err)
@@ -88,7 +147,7 @@ func authenticate(address string) (string, error) {
 	return "", fmt.Errorf("unable to authenticate")
 }
 
-func performAuthRequest(address, username string) (string, error) {
+func performAuthRequest(client *http.Client, address, username string) (string, error) {
 	// create a reader for the authentication request body
 	bodyBytes, err := json.Marshal(authRequest{
 		DeviceType: "HueKit Bridge#" + username,
@@ -102,7 +161,7 @@ func performAuthRequest(address, username string) (string, error) {
 	// create the request
 	req, err := http.NewRequest(
 		"POST",
-		"http://"+address+"/api",
+		"https://"+address+"/api",
 		bytes.NewBuffer(bodyBytes),
 	)
 
@@ -115,7 +174,7 @@ func performAuthRequest(address, username string) (string, error) {
 	}
 
 	// perform the http request
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
 
 	// error handling
 	if err != nil {
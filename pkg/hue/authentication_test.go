@@ -13,7 +13,7 @@ import (
 )
 
 func testServerAuth() *httptest.Server {
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
 			w.WriteHeader(http.StatusNotFound)
 			w.Write([]byte(`[]`))
@@ -80,14 +80,14 @@ func TestPerformAuthRequest(t *testing.T) {
 	}{
 		{
 			description:    "success",
-			address:        strings.TrimLeft(mockServer.URL, "htp:/"),
+			address:        strings.TrimPrefix(mockServer.URL, "https://"),
 			username:       "success",
 			expectedError:  false,
 			expectedResult: "success",
 		},
 		{
 			description:    "link button not pressed",
-			address:        strings.TrimLeft(mockServer.URL, "htp:/"),
+			address:        strings.TrimPrefix(mockServer.URL, "https://"),
 			username:       "notpressed",
 			expectedError:  true,
 			expectedResult: "",
@@ -96,6 +96,7 @@ func TestPerformAuthRequest(t *testing.T) {
 
 	for _, test := range tests {
 		result, err := performAuthRequest(
+			mockServer.Client(),
 			test.address,
 			test.username,
 		)
@@ -0,0 +1,58 @@
+package hue
+
+// ButtonAction Action of a button, that is reported by a switch
+type ButtonAction int
+
+// Actions of the buttons of ZLLSwitch, ZGPSwitch and ZHASwitch sensors
+const (
+	ButtonActionUnknown ButtonAction = iota
+	ButtonActionInitialPress
+	ButtonActionHold
+	ButtonActionShortRelease
+	ButtonActionLongRelease
+	ButtonActionDoublePress
+)
+
+// zgpButtons Mapping of the button events of a hue tap to its buttons.
+// The tap only reports which button was pressed.
+var zgpButtons = map[int]int{
+	34: 1,
+	16: 2,
+	17: 3,
+	18: 4,
+}
+
+// DecodeButtonEvent Decode the buttonevent of a switch into the number
+// of the button, starting with 1, and its action
+func DecodeButtonEvent(sensorType string, event int) (int, ButtonAction) {
+	// the hue tap reports one event per button
+	if sensorType == SensorTypeZGPSwitch {
+		button, ok := zgpButtons[event]
+
+		if !ok {
+			return 0, ButtonActionUnknown
+		}
+
+		return button, ButtonActionShortRelease
+	}
+
+	// all other switches report the button in the thousands and the
+	// action in the remaining digits, e.g. 1002 for a short release
+	// of the first button
+	button := event / 1000
+
+	switch event % 1000 {
+	case 0:
+		return button, ButtonActionInitialPress
+	case 1:
+		return button, ButtonActionHold
+	case 2:
+		return button, ButtonActionShortRelease
+	case 3:
+		return button, ButtonActionLongRelease
+	case 4:
+		return button, ButtonActionDoublePress
+	}
+
+	return button, ButtonActionUnknown
+}
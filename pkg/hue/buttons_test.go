@@ -0,0 +1,60 @@
+package hue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeButtonEvent(t *testing.T) {
+	tests := []struct {
+		description    string
+		sensorType     string
+		event          int
+		expectedButton int
+		expectedAction ButtonAction
+	}{
+		{
+			description:    "dimmer switch short release",
+			sensorType:     SensorTypeZLLSwitch,
+			event:          1002,
+			expectedButton: 1,
+			expectedAction: ButtonActionShortRelease,
+		},
+		{
+			description:    "dimmer switch long release",
+			sensorType:     SensorTypeZLLSwitch,
+			event:          4003,
+			expectedButton: 4,
+			expectedAction: ButtonActionLongRelease,
+		},
+		{
+			description:    "third party switch double press",
+			sensorType:     SensorTypeZHASwitch,
+			event:          1004,
+			expectedButton: 1,
+			expectedAction: ButtonActionDoublePress,
+		},
+		{
+			description:    "hue tap",
+			sensorType:     SensorTypeZGPSwitch,
+			event:          17,
+			expectedButton: 3,
+			expectedAction: ButtonActionShortRelease,
+		},
+		{
+			description:    "unknown hue tap event",
+			sensorType:     SensorTypeZGPSwitch,
+			event:          99,
+			expectedButton: 0,
+			expectedAction: ButtonActionUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		button, action := DecodeButtonEvent(test.sensorType, test.event)
+
+		assert.Equalf(t, test.expectedButton, button, test.description)
+		assert.Equalf(t, test.expectedAction, action, test.description)
+	}
+}
@@ -0,0 +1,311 @@
+package hue
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache Caching decorator for a Bridger. It serves lights and groups from
+// memory, which is refreshed by a single /lights or /groups request once
+// the ttl expired. Copies are returned, such that callers cannot change
+// the cached resources.
+type Cache struct {
+	bridge Bridger
+	ttl    time.Duration
+
+	mutex         sync.Mutex
+	lights        []*Light
+	updated       time.Time
+	groups        []*Group
+	groupsUpdated time.Time
+}
+
+// NewCache Wrap the given bridge with a cache, that keeps the lights
+// for the given ttl
+func NewCache(bridge Bridger, ttl time.Duration) *Cache {
+	return &Cache{
+		bridge: bridge,
+		ttl:    ttl,
+	}
+}
+
+// Lights Return all lights from the cache and refresh it, if it is
+// expired
+func (c *Cache) Lights() ([]*Light, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// refresh the cache, if required
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	lights := make([]*Light, 0, len(c.lights))
+
+	for _, light := range c.lights {
+		lights = append(lights, copyLight(light))
+	}
+
+	return lights, nil
+}
+
+// Light Return a light by its id from the cache and refresh it, if it
+// is expired
+func (c *Cache) Light(id string) (*Light, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// refresh the cache, if required
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	// search the light in the cache
+	for _, light := range c.lights {
+		if light.ID == id {
+			return copyLight(light), nil
+		}
+	}
+
+	return nil, fmt.Errorf("light %s not found", id)
+}
+
+// LightUpdateState Update the state of a light and invalidate the
+// cache, such that the next read returns the new state
+func (c *Cache) LightUpdateState(light *Light, state *State) error {
+	// invalidate the cache, even if the update failed, as the state
+	// of the light is unknown then
+	defer c.Invalidate()
+
+	return c.bridge.LightUpdateState(light, state)
+}
+
+// Groups Return all groups from the cache and refresh it, if it is
+// expired
+func (c *Cache) Groups() ([]*Group, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// refresh the cache, if required
+	if err := c.refreshGroups(); err != nil {
+		return nil, err
+	}
+
+	groups := make([]*Group, 0, len(c.groups))
+
+	for _, group := range c.groups {
+		groups = append(groups, copyGroup(group))
+	}
+
+	return groups, nil
+}
+
+// Group Return a group by its id from the cache and refresh it, if it
+// is expired
+func (c *Cache) Group(id string) (*Group, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// refresh the cache, if required
+	if err := c.refreshGroups(); err != nil {
+		return nil, err
+	}
+
+	// search the group in the cache
+	for _, group := range c.groups {
+		if group.ID == id {
+			return copyGroup(group), nil
+		}
+	}
+
+	// the bridge does not list every group, e.g. the group 0 with
+	// all lights of the v1 api
+	return c.bridge.Group(id)
+}
+
+// GroupUpdateAction Update the state of all lights in a group and
+// invalidate the cache, as the state of the lights changed
+func (c *Cache) GroupUpdateAction(group *Group, action *State) error {
+	defer c.Invalidate()
+
+	return c.bridge.GroupUpdateAction(group, action)
+}
+
+// Scenes Query and return all scenes from the bridge
+func (c *Cache) Scenes() ([]*Scene, error) {
+	return c.bridge.Scenes()
+}
+
+// RecallScene Activate a scene and invalidate the cache, as the state
+// of the lights changed
+func (c *Cache) RecallScene(scene *Scene) error {
+	defer c.Invalidate()
+
+	return c.bridge.RecallScene(scene)
+}
+
+// Sensors Query and return all sensors from the bridge
+func (c *Cache) Sensors() ([]*Sensor, error) {
+	return c.bridge.Sensors()
+}
+
+// Sensor Query and return a sensor by its id from the bridge
+func (c *Cache) Sensor(id string) (*Sensor, error) {
+	return c.bridge.Sensor(id)
+}
+
+// Subscribe Forward the events of the wrapped bridge, if it supports
+// them, and invalidate the cache on every event
+func (c *Cache) Subscribe(stop <-chan struct{}) (<-chan *Event, error) {
+	subscriber, ok := c.bridge.(Subscriber)
+	if !ok {
+		return nil, ErrSubscriptionNotSupported
+	}
+
+	// subscribe to the wrapped bridge
+	events, err := subscriber.Subscribe(stop)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	forwarded := make(chan *Event)
+
+	go func() {
+		defer close(forwarded)
+
+		for event := range events {
+			// the cached lights are outdated now
+			c.Invalidate()
+
+			select {
+			case forwarded <- event:
+			case <-stop:
+			}
+		}
+	}()
+
+	return forwarded, nil
+}
+
+// Invalidate Mark the cache as expired
+func (c *Cache) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.updated = time.Time{}
+	c.groupsUpdated = time.Time{}
+}
+
+// refresh Fetch all lights with one request, if the cache is expired.
+// The mutex must be held by the caller.
+func (c *Cache) refresh() error {
+	// return early, if the cache is still valid
+	if !c.updated.IsZero() && time.Since(c.updated) < c.ttl {
+		return nil
+	}
+
+	// fetch all lights
+	lights, err := c.bridge.Lights()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	// save the lights
+	c.lights = lights
+	c.updated = time.Now()
+
+	return nil
+}
+
+// refreshGroups Fetch all groups with one request, if the cache is
+// expired. The mutex must be held by the caller.
+func (c *Cache) refreshGroups() error {
+	// return early, if the cache is still valid
+	if !c.groupsUpdated.IsZero() && time.Since(c.groupsUpdated) < c.ttl {
+		return nil
+	}
+
+	// fetch all groups
+	groups, err := c.bridge.Groups()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	// save the groups
+	c.groups = groups
+	c.groupsUpdated = time.Now()
+
+	return nil
+}
+
+// copyLight Return a deep copy of the light
+func copyLight(light *Light) *Light {
+	result := *light
+	result.State = copyState(light.State)
+
+	if light.PointSymbol != nil {
+		result.PointSymbol = make(map[string]string, len(light.PointSymbol))
+
+		for key, value := range light.PointSymbol {
+			result.PointSymbol[key] = value
+		}
+	}
+
+	if light.Capabilities != nil {
+		capabilities := *light.Capabilities
+		result.Capabilities = &capabilities
+
+		if control := light.Capabilities.Control; control != nil {
+			copied := *control
+			copied.ColorGamut = nil
+
+			for _, point := range control.ColorGamut {
+				copied.ColorGamut = append(copied.ColorGamut, append([]float64(nil), point...))
+			}
+
+			capabilities.Control = &copied
+		}
+	}
+
+	return &result
+}
+
+// copyGroup Return a deep copy of the group
+func copyGroup(group *Group) *Group {
+	result := *group
+	result.Lights = append([]string(nil), group.Lights...)
+	result.Action = copyState(group.Action)
+
+	if group.State != nil {
+		state := *group.State
+		result.State = &state
+	}
+
+	return &result
+}
+
+// copyState Return a deep copy of the state
+func copyState(state *State) *State {
+	if state == nil {
+		return nil
+	}
+
+	result := *state
+	result.XY = append([]float64(nil), state.XY...)
+
+	for _, value := range []**int{&result.Hue, &result.Saturation, &result.TransitionTime} {
+		if *value != nil {
+			copied := **value
+			*value = &copied
+		}
+	}
+
+	return &result
+}
@@ -0,0 +1,208 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var requests int32
+
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Method == "PUT" {
+			w.WriteHeader(200)
+			w.Write([]byte(`[{"success": {}}]`))
+			return
+		}
+
+		if strings.HasSuffix(r.RequestURI, "/groups") {
+			w.Write([]byte(`{"1": {"name": "Living room", "type": "Room", "lights": ["1"], "action": {"on": true}, "state": {"all_on": true, "any_on": true}}}`))
+			return
+		}
+
+		lightsHandler(w, r)
+	}))
+	defer mockServer.Close()
+
+	bridge := &Bridge{
+		address:  strings.TrimPrefix(mockServer.URL, "https://"),
+		username: "success",
+		client:   mockServer.Client(),
+	}
+
+	tests := []struct {
+		description      string
+		ttl              time.Duration
+		run              func(*Cache) error
+		expectedError    bool
+		expectedRequests int32
+	}{
+		{
+			description: "reads within the ttl",
+			ttl:         time.Minute,
+			run: func(c *Cache) error {
+				for i := 0; i < 20; i++ {
+					if _, err := c.Light("1"); err != nil {
+						return err
+					}
+				}
+
+				_, err := c.Lights()
+
+				return err
+			},
+			expectedError:    false,
+			expectedRequests: 1,
+		},
+		{
+			description: "expired ttl",
+			ttl:         0,
+			run: func(c *Cache) error {
+				for i := 0; i < 3; i++ {
+					if _, err := c.Light("1"); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			expectedError:    false,
+			expectedRequests: 3,
+		},
+		{
+			description: "invalidation on state update",
+			ttl:         time.Minute,
+			run: func(c *Cache) error {
+				light, err := c.Light("1")
+
+				if err != nil {
+					return err
+				}
+
+				if err := c.LightUpdateState(light, &State{On: false}); err != nil {
+					return err
+				}
+
+				_, err = c.Light("1")
+
+				return err
+			},
+			expectedError:    false,
+			expectedRequests: 3,
+		},
+		{
+			description: "group reads within the ttl",
+			ttl:         time.Minute,
+			run: func(c *Cache) error {
+				for i := 0; i < 20; i++ {
+					if _, err := c.Group("1"); err != nil {
+						return err
+					}
+				}
+
+				_, err := c.Groups()
+
+				return err
+			},
+			expectedError:    false,
+			expectedRequests: 1,
+		},
+		{
+			description: "invalidation on group action",
+			ttl:         time.Minute,
+			run: func(c *Cache) error {
+				group, err := c.Group("1")
+
+				if err != nil {
+					return err
+				}
+
+				if err := c.GroupUpdateAction(group, &State{On: false}); err != nil {
+					return err
+				}
+
+				_, err = c.Group("1")
+
+				return err
+			},
+			expectedError:    false,
+			expectedRequests: 3,
+		},
+		{
+			description: "unknown light",
+			ttl:         time.Minute,
+			run: func(c *Cache) error {
+				_, err := c.Light("42")
+
+				return err
+			},
+			expectedError:    true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, test := range tests {
+		atomic.StoreInt32(&requests, 0)
+
+		err := test.run(NewCache(bridge, test.ttl))
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+		assert.Equalf(t, test.expectedRequests, atomic.LoadInt32(&requests), test.description)
+	}
+}
+
+func TestCache_Copies(t *testing.T) {
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.RequestURI, "/groups") {
+			w.Write([]byte(`{"1": {"name": "Living room", "type": "Room", "lights": ["1"], "action": {"on": true, "xy": [0.3, 0.3]}, "state": {"all_on": true, "any_on": true}}}`))
+			return
+		}
+
+		lightsHandler(w, r)
+	}))
+	defer mockServer.Close()
+
+	cache := NewCache(&Bridge{
+		address:  strings.TrimPrefix(mockServer.URL, "https://"),
+		username: "success",
+		client:   mockServer.Client(),
+	}, time.Minute)
+
+	// changing a returned light does not change the cached one
+	light, err := cache.Light("1")
+	assert.Nil(t, err)
+
+	light.Name = "Changed"
+	light.State.On = false
+	*light.State.Hue = 0
+	light.State.XY[0] = 0
+
+	lights, err := cache.Lights()
+	assert.Nil(t, err)
+	assert.Equal(t, "TV Left", lights[0].Name)
+	assert.True(t, lights[0].State.On)
+	assert.Equal(t, 13122, *lights[0].State.Hue)
+	assert.Equal(t, 0.5119, lights[0].State.XY[0])
+
+	// changing a returned group does not change the cached one
+	group, err := cache.Group("1")
+	assert.Nil(t, err)
+
+	group.Lights[0] = "2"
+	group.Action.XY[0] = 0
+	group.State.AllOn = false
+
+	groups, err := cache.Groups()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, groups[0].Lights)
+	assert.Equal(t, 0.3, groups[0].Action.XY[0])
+	assert.True(t, groups[0].State.AllOn)
+}
@@ -0,0 +1,94 @@
+package hue
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// ErrCertificateChanged Returned, if the bridge presents another
+// certificate than the pinned one
+var ErrCertificateChanged = errors.New("certificate of the bridge changed")
+
+// certificateKey Key of the pinned certificate fingerprint in the store
+const certificateKey = "bridge_certificate"
+
+// newPinnedClient Create a http client, that trusts the certificate of
+// the bridge on the first connection and refuses all other certificates
+// afterwards. Connections are established with the locator, if given.
+func newPinnedClient(store store.Store, locator *Locator) *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			// the bridge uses a self-signed certificate, that
+			// cannot be verified with a ca. It is verified by
+			// its pinned fingerprint instead.
+			InsecureSkipVerify:    true, // #nosec G402
+			VerifyPeerCertificate: pinCertificate(store),
+		},
+	}
+
+	// follow the bridge, when its address changes
+	if locator != nil {
+		transport.DialContext = locator.DialContext
+	}
+
+	return &http.Client{
+		Transport: &instrumentedTransport{next: transport},
+	}
+}
+
+// pinCertificate Return a verification function, that saves the
+// fingerprint of the first seen certificate in the store and compares
+// all following certificates against it
+func pinCertificate(store store.Store) func([][]byte, [][]*x509.Certificate) error {
+	var mutex sync.Mutex
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		// the leaf certificate is the first one
+		if len(rawCerts) == 0 {
+			return errors.New("the bridge presented no certificate")
+		}
+
+		fingerprint := certificateFingerprint(rawCerts[0])
+
+		// avoid pinning different certificates with parallel
+		// connections
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		pinned, err := store.Get(certificateKey)
+
+		// trust the certificate on the first connection
+		if err != nil {
+			log.WithFields(log.Fields{
+				"fingerprint": fingerprint,
+			}).Info("pinning the certificate of the bridge")
+
+			return store.Set(certificateKey, fingerprint)
+		}
+
+		// refuse the connection, if the certificate changed
+		if pinned != fingerprint {
+			return fmt.Errorf("%w: expected %s, got %s", ErrCertificateChanged, pinned, fingerprint)
+		}
+
+		return nil
+	}
+}
+
+// certificateFingerprint Return the hex encoded sha256 hash of the der
+// encoded certificate
+func certificateFingerprint(der []byte) string {
+	hash := sha256.Sum256(der)
+
+	return hex.EncodeToString(hash[:])
+}
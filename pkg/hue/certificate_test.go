@@ -0,0 +1,74 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestNewPinnedClient(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`{}`))
+	})
+
+	mockServer := httptest.NewTLSServer(handler)
+	defer mockServer.Close()
+
+	tests := []struct {
+		description   string
+		pinned        string
+		expectedError error
+	}{
+		{
+			description:   "trust on first use",
+			pinned:        "",
+			expectedError: nil,
+		},
+		{
+			description:   "pinned certificate",
+			pinned:        certificateFingerprint(mockServer.Certificate().Raw),
+			expectedError: nil,
+		},
+		{
+			description:   "changed certificate",
+			pinned:        "0123456789abcdef",
+			expectedError: ErrCertificateChanged,
+		},
+	}
+
+	for _, test := range tests {
+		// a changed certificate is simulated by pinning another
+		// fingerprint
+		memory := store.NewMemory(nil)
+
+		if test.pinned != "" {
+			assert.Nilf(t, memory.Set(certificateKey, test.pinned), test.description)
+		}
+
+		bridge := &Bridge{
+			address:  strings.TrimPrefix(mockServer.URL, "https://"),
+			username: "success",
+			client:   newPinnedClient(memory, nil),
+		}
+
+		_, err := bridge.Lights()
+
+		assert.Truef(t, errors.Is(err, test.expectedError), test.description)
+
+		// the certificate of the server must be pinned now, unless
+		// it was refused
+		if test.expectedError == nil {
+			pinned, err := memory.Get(certificateKey)
+
+			assert.Nilf(t, err, test.description)
+			assert.Equalf(t, certificateFingerprint(mockServer.Certificate().Raw), pinned, test.description)
+		}
+	}
+}
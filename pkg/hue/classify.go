@@ -0,0 +1,80 @@
+package hue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hueProductIDPrefix Prefix of the product ids of genuine hue devices,
+// e.g. Philips-LCT015-1-A19ECLv5
+const hueProductIDPrefix = "Philips-"
+
+// hueManufacturers Manufacturer names, that genuine hue devices report.
+// Older firmwares report Philips, newer ones Signify.
+var hueManufacturers = map[string]bool{
+	"signify netherlands b.v.": true,
+	"philips":                  true,
+}
+
+// Classification Result of the decision, if a device is a genuine hue
+// device, including the reason for the decision
+type Classification struct {
+	// Genuine Set, if the device is made by hue. Genuine devices are
+	// published in homekit by the hue bridge itself.
+	Genuine bool
+
+	// Reason Human readable explanation of the decision
+	Reason string
+}
+
+// Classify Decide, if a device is a genuine hue device based on its
+// product id, manufacturer name and model id. The product id and the
+// manufacturer name are preferred, as they are reported by every recent
+// firmware. The table of known models is used for devices without them.
+func Classify(manufacturer, productID, modelID string) *Classification {
+	// genuine devices have a product id with the hue prefix
+	if strings.HasPrefix(productID, hueProductIDPrefix) {
+		return &Classification{
+			Genuine: true,
+			Reason:  fmt.Sprintf("product id '%s' is from hue", productID),
+		}
+	}
+
+	// trust the manufacturer, if it is reported
+	if manufacturer != "" {
+		if hueManufacturers[strings.ToLower(manufacturer)] {
+			return &Classification{
+				Genuine: true,
+				Reason:  fmt.Sprintf("manufacturer '%s' is hue", manufacturer),
+			}
+		}
+
+		return &Classification{
+			Genuine: false,
+			Reason:  fmt.Sprintf("manufacturer '%s' is not hue", manufacturer),
+		}
+	}
+
+	// fall back to the known models
+	if product, ok := hueModels[modelID]; ok {
+		return &Classification{
+			Genuine: true,
+			Reason:  fmt.Sprintf("model '%s' is the hue product '%s'", modelID, product),
+		}
+	}
+
+	return &Classification{
+		Genuine: false,
+		Reason:  fmt.Sprintf("model '%s' of an unknown manufacturer is no known hue product", modelID),
+	}
+}
+
+// Classify Decide, if the light is a genuine hue device
+func (l *Light) Classify() *Classification {
+	return Classify(l.ManufacturerName, l.ProductID, l.ModelID)
+}
+
+// Classify Decide, if the sensor is a genuine hue device
+func (s *Sensor) Classify() *Classification {
+	return Classify(s.ManufacturerName, s.ProductID, s.ModelID)
+}
@@ -0,0 +1,112 @@
+package hue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		description     string
+		manufacturer    string
+		productID       string
+		modelID         string
+		expectedGenuine bool
+	}{
+		{
+			description:     "hue product id",
+			productID:       "Philips-LCT015-1-A19ECLv5",
+			modelID:         "LCT015",
+			expectedGenuine: true,
+		},
+		{
+			description:     "signify manufacturer",
+			manufacturer:    "Signify Netherlands B.V.",
+			modelID:         "LWB010",
+			expectedGenuine: true,
+		},
+		{
+			description:     "philips manufacturer",
+			manufacturer:    "Philips",
+			modelID:         "LCT001",
+			expectedGenuine: true,
+		},
+		{
+			description:     "third party model with a hue-like model id",
+			manufacturer:    "Sunricher",
+			modelID:         "ZGR904",
+			expectedGenuine: false,
+		},
+		{
+			description:     "known model without manufacturer",
+			modelID:         "LTW012",
+			expectedGenuine: true,
+		},
+		{
+			description:     "unknown model without manufacturer",
+			modelID:         "Plug",
+			expectedGenuine: false,
+		},
+	}
+
+	for _, test := range tests {
+		result := Classify(test.manufacturer, test.productID, test.modelID)
+
+		assert.Equalf(t, test.expectedGenuine, result.Genuine, test.description)
+		assert.NotEmptyf(t, result.Reason, test.description)
+	}
+}
+
+func TestClassify_Corpus(t *testing.T) {
+	// ids of the genuine hue lights in every /lights dump
+	tests := []struct {
+		file            string
+		expectedGenuine map[string]bool
+	}{
+		{
+			file: "mixed_home.json",
+			expectedGenuine: map[string]bool{
+				"1": true,
+				"2": true,
+				"3": false,
+				"4": false,
+				"5": false,
+				"6": false,
+			},
+		},
+		{
+			file: "old_firmware.json",
+			expectedGenuine: map[string]bool{
+				"1": true,
+				"2": true,
+				"3": true,
+				"4": false,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", "lights", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lights map[string]*Light
+
+		if err := json.Unmarshal(data, &lights); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Lenf(t, lights, len(test.expectedGenuine), test.file)
+
+		for id, light := range lights {
+			result := light.Classify()
+
+			assert.Equalf(t, test.expectedGenuine[id], result.Genuine, "%s: light %s, %s", test.file, id, result.Reason)
+		}
+	}
+}
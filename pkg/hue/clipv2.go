@@ -0,0 +1,1112 @@
+package hue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dj95/huekit/pkg/color"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// ErrUnsupportedState Returned, if a field of the state cannot be applied
+// with the v2 api
+var ErrUnsupportedState = errors.New("state not supported")
+
+// BridgeV2 Implements handling with the hue bridge over the CLIP v2 api.
+// Ids of the v1 api are used for all devices, that have one, such that
+// both implementations can be exchanged.
+type BridgeV2 struct {
+	address  string
+	username string
+	client   *http.Client
+
+	mutex         sync.Mutex
+	devices       map[string]*v2Device
+	connectivity  map[string]*v2Connectivity
+	lights        map[string]*v2Light
+	groups        map[string]*v2Group
+	groupedLights map[string]*v2GroupedLight
+	scenes        map[string]*v2Scene
+	sensors       map[string]*v2Sensor
+}
+
+type v2Response struct {
+	Errors []struct {
+		Description string `json:"description"`
+	} `json:"errors"`
+	Data json.RawMessage `json:"data"`
+}
+
+type v2Reference struct {
+	RID   string `json:"rid"`
+	RType string `json:"rtype"`
+}
+
+type v2Metadata struct {
+	Name      string `json:"name"`
+	Archetype string `json:"archetype"`
+	ControlID int    `json:"control_id"`
+}
+
+type v2Device struct {
+	ID          string `json:"id"`
+	ProductData struct {
+		ModelID          string `json:"model_id"`
+		ManufacturerName string `json:"manufacturer_name"`
+		ProductName      string `json:"product_name"`
+		ProductArchetype string `json:"product_archetype"`
+		SoftwareVersion  string `json:"software_version"`
+	} `json:"product_data"`
+	Metadata v2Metadata    `json:"metadata"`
+	Services []v2Reference `json:"services"`
+}
+
+type v2Connectivity struct {
+	ID     string      `json:"id"`
+	Owner  v2Reference `json:"owner"`
+	Status string      `json:"status"`
+	MAC    string      `json:"mac_address"`
+}
+
+type v2On struct {
+	On bool `json:"on"`
+}
+
+type v2Dimming struct {
+	Brightness float64 `json:"brightness"`
+}
+
+type v2Dynamics struct {
+	Duration int `json:"duration"`
+}
+
+type v2Alert struct {
+	Action string `json:"action"`
+}
+
+type v2Effects struct {
+	Effect string `json:"effect"`
+}
+
+type v2ColorTemperature struct {
+	Mirek      int  `json:"mirek"`
+	MirekValid bool `json:"mirek_valid"`
+}
+
+type v2XY struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type v2Color struct {
+	XY        v2XY     `json:"xy"`
+	Gamut     *v2Gamut `json:"gamut,omitempty"`
+	GamutType string   `json:"gamut_type,omitempty"`
+}
+
+type v2Gamut struct {
+	Red   v2XY `json:"red"`
+	Green v2XY `json:"green"`
+	Blue  v2XY `json:"blue"`
+}
+
+type v2Light struct {
+	ID               string              `json:"id"`
+	IDv1             string              `json:"id_v1"`
+	Owner            v2Reference         `json:"owner"`
+	Metadata         v2Metadata          `json:"metadata"`
+	On               *v2On               `json:"on"`
+	Dimming          *v2Dimming          `json:"dimming"`
+	ColorTemperature *v2ColorTemperature `json:"color_temperature"`
+	Color            *v2Color            `json:"color"`
+}
+
+type v2Group struct {
+	ID       string        `json:"id"`
+	IDv1     string        `json:"id_v1"`
+	Type     string        `json:"type"`
+	Metadata v2Metadata    `json:"metadata"`
+	Children []v2Reference `json:"children"`
+	Services []v2Reference `json:"services"`
+}
+
+type v2GroupedLight struct {
+	ID      string      `json:"id"`
+	IDv1    string      `json:"id_v1"`
+	Owner   v2Reference `json:"owner"`
+	On      *v2On       `json:"on"`
+	Dimming *v2Dimming  `json:"dimming"`
+}
+
+type v2Scene struct {
+	ID       string        `json:"id"`
+	IDv1     string        `json:"id_v1"`
+	Metadata v2Metadata    `json:"metadata"`
+	Group    v2Reference   `json:"group"`
+	Actions  []v2SceneItem `json:"actions"`
+}
+
+type v2SceneItem struct {
+	Target v2Reference `json:"target"`
+}
+
+type v2Sensor struct {
+	ID       string      `json:"id"`
+	IDv1     string      `json:"id_v1"`
+	Type     string      `json:"type"`
+	Owner    v2Reference `json:"owner"`
+	Metadata v2Metadata  `json:"metadata"`
+	Enabled  bool        `json:"enabled"`
+	Motion   *struct {
+		Motion bool `json:"motion"`
+	} `json:"motion"`
+	Temperature *struct {
+		Temperature float64 `json:"temperature"`
+	} `json:"temperature"`
+	Light *struct {
+		LightLevel int `json:"light_level"`
+	} `json:"light"`
+	ContactReport *struct {
+		State   string `json:"state"`
+		Changed string `json:"changed"`
+	} `json:"contact_report"`
+	Button *struct {
+		ButtonReport *struct {
+			Updated string `json:"updated"`
+			Event   string `json:"event"`
+		} `json:"button_report"`
+	} `json:"button"`
+}
+
+// v2SensorTypes Mapping of the v2 sensor resources to the types of
+// the v1 api
+var v2SensorTypes = map[string]string{
+	"motion":      SensorTypeZLLPresence,
+	"temperature": SensorTypeZLLTemperature,
+	"light_level": SensorTypeZLLLightLevel,
+	"contact":     SensorTypeZLLOpenClose,
+	"button":      SensorTypeZLLSwitch,
+}
+
+// v2GroupTypes Mapping of the v2 group resources to the types of the
+// v1 api
+var v2GroupTypes = map[string]string{
+	"room": "Room",
+	"zone": "Zone",
+}
+
+// v2ButtonEvents Mapping of the v2 button events to the last digits of
+// the v1 buttonevent
+var v2ButtonEvents = map[string]int{
+	"initial_press":        0,
+	"repeat":               1,
+	"short_release":        2,
+	"long_release":         3,
+	"double_short_release": 4,
+}
+
+// NewBridgeV2 Instantiates a new bridge, that uses the CLIP v2 api, with
+// the given store. If no authentication is saved, it will authenticate
+// against the bridge
+func NewBridgeV2(address string, store store.Store) (*BridgeV2, error) {
+	// locate the bridge
+	locator := NewLocator(address, NewDiscovery(discoveryTimeout), store)
+
+	address, err := locator.Address()
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	// create a client, that pins the certificate of the bridge
+	client := newPinnedClient(store, locator)
+
+	// load the username or authenticate. The username of the v1 api
+	// is the application key of the v2 api.
+	username, err := loadUsername(client, address, store)
+
+	// handle authentication error
+	if err != nil {
+		return nil, err
+	}
+
+	return newBridgeV2(address, username, client), nil
+}
+
+func newBridgeV2(address, username string, client *http.Client) *BridgeV2 {
+	return &BridgeV2{
+		address:       address,
+		username:      username,
+		client:        client,
+		devices:       map[string]*v2Device{},
+		connectivity:  map[string]*v2Connectivity{},
+		lights:        map[string]*v2Light{},
+		groups:        map[string]*v2Group{},
+		groupedLights: map[string]*v2GroupedLight{},
+		scenes:        map[string]*v2Scene{},
+		sensors:       map[string]*v2Sensor{},
+	}
+}
+
+// Lights Query and return all lights
+func (b *BridgeV2) Lights() ([]*Light, error) {
+	// refresh the lights and the devices, they belong to
+	if err := b.refresh("device", "zigbee_connectivity", "light"); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var lights []*Light
+
+	for _, light := range b.lights {
+		lights = append(lights, b.convertLight(light))
+	}
+
+	// sort the lights by their id in order to always return them
+	// in the same order
+	sort.Slice(lights, func(i, j int) bool {
+		return lessID(lights[i].ID, lights[j].ID)
+	})
+
+	return lights, nil
+}
+
+// Light Query and return a light by its id
+func (b *BridgeV2) Light(id string) (*Light, error) {
+	lights, err := b.Lights()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, light := range lights {
+		if light.ID == id {
+			return light, nil
+		}
+	}
+
+	return nil, fmt.Errorf("light %s not found", id)
+}
+
+// LightUpdateState Update the state of a light
+func (b *BridgeV2) LightUpdateState(light *Light, state *State) error {
+	return countUpdateError(b.updateLight(light, state))
+}
+
+// updateLight Update the state of a light with its v2 resource
+func (b *BridgeV2) updateLight(light *Light, state *State) error {
+	rid, err := b.lookup(func() string {
+		return b.resourceID(light.ID, "light")
+	}, "light")
+
+	if err != nil {
+		return err
+	}
+
+	if rid == "" {
+		return fmt.Errorf("light %s not found", light.ID)
+	}
+
+	// the current color of the light is needed in order to convert
+	// hue and saturation
+	b.mutex.Lock()
+	body, err := convertState(state, b.lights[rid])
+	b.mutex.Unlock()
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	return b.put("light", rid, body)
+}
+
+// Groups Query and return all rooms and zones
+func (b *BridgeV2) Groups() ([]*Group, error) {
+	// refresh the groups and the lights, they contain
+	if err := b.refresh("device", "light", "room", "zone", "grouped_light"); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var groups []*Group
+
+	for _, group := range b.groups {
+		groups = append(groups, b.convertGroup(group))
+	}
+
+	// sort the groups by their id in order to always return them
+	// in the same order
+	sort.Slice(groups, func(i, j int) bool {
+		return lessID(groups[i].ID, groups[j].ID)
+	})
+
+	return groups, nil
+}
+
+// Group Query and return a room or zone by its id
+func (b *BridgeV2) Group(id string) (*Group, error) {
+	groups, err := b.Groups()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		if group.ID == id {
+			return group, nil
+		}
+	}
+
+	return nil, fmt.Errorf("group %s not found", id)
+}
+
+// GroupUpdateAction Update the state of all lights in a room or zone
+// with a single request
+func (b *BridgeV2) GroupUpdateAction(group *Group, action *State) error {
+	// the state of a group is changed with its grouped light
+	rid, err := b.lookup(func() string {
+		return b.groupedLightID(b.resourceID(group.ID, "room", "zone"))
+	}, "room", "zone")
+
+	if err != nil {
+		return err
+	}
+
+	if rid == "" {
+		return fmt.Errorf("group %s not found", group.ID)
+	}
+
+	body, err := convertState(action, nil)
+
+	// error handling
+	if err != nil {
+		return err
+	}
+
+	// grouped lights only support power, brightness and alerts
+	delete(body, "color_temperature")
+	delete(body, "color")
+	delete(body, "effects")
+
+	return b.put("grouped_light", rid, body)
+}
+
+// Scenes Query and return all scenes
+func (b *BridgeV2) Scenes() ([]*Scene, error) {
+	if err := b.refresh("device", "light", "room", "zone", "scene"); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var scenes []*Scene
+
+	for _, scene := range b.scenes {
+		result := &Scene{
+			ID:    v1ID(scene.IDv1, scene.ID),
+			Name:  scene.Metadata.Name,
+			Type:  "GroupScene",
+			Group: b.groupID(scene.Group.RID),
+		}
+
+		// collect the lights of the scene
+		for _, action := range scene.Actions {
+			if light, ok := b.lights[action.Target.RID]; ok {
+				result.Lights = append(result.Lights, v1ID(light.IDv1, light.ID))
+			}
+		}
+
+		scenes = append(scenes, result)
+	}
+
+	// sort the scenes by their id in order to always return them
+	// in the same order
+	sort.Slice(scenes, func(i, j int) bool {
+		return lessID(scenes[i].ID, scenes[j].ID)
+	})
+
+	return scenes, nil
+}
+
+// RecallScene Activate a scene
+func (b *BridgeV2) RecallScene(scene *Scene) error {
+	rid, err := b.lookup(func() string {
+		return b.resourceID(scene.ID, "scene")
+	}, "scene")
+
+	if err != nil {
+		return err
+	}
+
+	if rid == "" {
+		return fmt.Errorf("scene %s not found", scene.ID)
+	}
+
+	return b.put("scene", rid, map[string]interface{}{
+		"recall": map[string]string{"action": "active"},
+	})
+}
+
+// Sensors Query and return all sensors
+func (b *BridgeV2) Sensors() ([]*Sensor, error) {
+	if err := b.refresh("device", "zigbee_connectivity", "motion", "temperature", "light_level", "contact", "button"); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sensorMap := map[string]*Sensor{}
+
+	for _, sensor := range b.sensors {
+		converted := b.convertSensor(sensor)
+
+		// all buttons of a switch share the same v1 sensor. Only the
+		// last pressed button is reported for it.
+		if existing, ok := sensorMap[converted.ID]; ok && existing.State.LastUpdated > converted.State.LastUpdated {
+			continue
+		}
+
+		sensorMap[converted.ID] = converted
+	}
+
+	var sensors []*Sensor
+
+	for _, sensor := range sensorMap {
+		sensors = append(sensors, sensor)
+	}
+
+	// sort the sensors by their id in order to always return them
+	// in the same order
+	sort.Slice(sensors, func(i, j int) bool {
+		return lessID(sensors[i].ID, sensors[j].ID)
+	})
+
+	return sensors, nil
+}
+
+// Sensor Query and return a sensor by its id
+func (b *BridgeV2) Sensor(id string) (*Sensor, error) {
+	sensors, err := b.Sensors()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sensor := range sensors {
+		if sensor.ID == id {
+			return sensor, nil
+		}
+	}
+
+	return nil, fmt.Errorf("sensor %s not found", id)
+}
+
+// refresh Fetch the given resource types and replace the known
+// resources with them
+func (b *BridgeV2) refresh(types ...string) error {
+	for _, rtype := range types {
+		var data []json.RawMessage
+
+		// fetch all resources of the type
+		if err := b.get("/clip/v2/resource/"+rtype, &data); err != nil {
+			return err
+		}
+
+		b.mutex.Lock()
+
+		// remove the known resources of the type, such that deleted
+		// resources do not stay in memory
+		b.clear(rtype)
+
+		for _, raw := range data {
+			b.apply(rtype, raw)
+		}
+
+		b.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// clear Remove all known resources of the given type. The mutex must be
+// held by the caller.
+func (b *BridgeV2) clear(rtype string) {
+	switch rtype {
+	case "device":
+		b.devices = map[string]*v2Device{}
+	case "zigbee_connectivity":
+		b.connectivity = map[string]*v2Connectivity{}
+	case "light":
+		b.lights = map[string]*v2Light{}
+	case "grouped_light":
+		b.groupedLights = map[string]*v2GroupedLight{}
+	case "scene":
+		b.scenes = map[string]*v2Scene{}
+	case "room", "zone":
+		for id, group := range b.groups {
+			if group.Type == rtype {
+				delete(b.groups, id)
+			}
+		}
+	default:
+		if _, ok := v2SensorTypes[rtype]; !ok {
+			return
+		}
+
+		for id, sensor := range b.sensors {
+			if sensor.Type == rtype {
+				delete(b.sensors, id)
+			}
+		}
+	}
+}
+
+// apply Merge the json encoded resource into the known resource with the
+// same id. Only the fields contained in the json are changed, which
+// allows applying partial updates from the event stream. The mutex must
+// be held by the caller.
+func (b *BridgeV2) apply(rtype string, raw json.RawMessage) {
+	var ref struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(raw, &ref); err != nil || ref.ID == "" {
+		return
+	}
+
+	var target interface{}
+
+	// find or create the resource
+	switch rtype {
+	case "device":
+		if _, ok := b.devices[ref.ID]; !ok {
+			b.devices[ref.ID] = &v2Device{}
+		}
+
+		target = b.devices[ref.ID]
+	case "zigbee_connectivity":
+		if _, ok := b.connectivity[ref.ID]; !ok {
+			b.connectivity[ref.ID] = &v2Connectivity{}
+		}
+
+		target = b.connectivity[ref.ID]
+	case "light":
+		if _, ok := b.lights[ref.ID]; !ok {
+			b.lights[ref.ID] = &v2Light{}
+		}
+
+		target = b.lights[ref.ID]
+	case "room", "zone":
+		if _, ok := b.groups[ref.ID]; !ok {
+			b.groups[ref.ID] = &v2Group{Type: rtype}
+		}
+
+		target = b.groups[ref.ID]
+	case "grouped_light":
+		if _, ok := b.groupedLights[ref.ID]; !ok {
+			b.groupedLights[ref.ID] = &v2GroupedLight{}
+		}
+
+		target = b.groupedLights[ref.ID]
+	case "scene":
+		if _, ok := b.scenes[ref.ID]; !ok {
+			b.scenes[ref.ID] = &v2Scene{}
+		}
+
+		target = b.scenes[ref.ID]
+	default:
+		if _, ok := v2SensorTypes[rtype]; !ok {
+			return
+		}
+
+		if _, ok := b.sensors[ref.ID]; !ok {
+			b.sensors[ref.ID] = &v2Sensor{Type: rtype}
+		}
+
+		target = b.sensors[ref.ID]
+	}
+
+	// merge the changed fields into the resource
+	_ = json.Unmarshal(raw, target)
+}
+
+// convertLight Convert a v2 light into a light of the v1 api. The mutex
+// must be held by the caller.
+func (b *BridgeV2) convertLight(light *v2Light) *Light {
+	result := &Light{
+		ID:    v1ID(light.IDv1, light.ID),
+		Name:  light.Metadata.Name,
+		State: &State{},
+	}
+
+	device := b.devices[light.Owner.RID]
+
+	// add the product information of the device
+	if device != nil {
+		result.Name = device.Metadata.Name
+		result.ModelID = device.ProductData.ModelID
+		result.ManufacturerName = device.ProductData.ManufacturerName
+		result.SoftwareVersion = device.ProductData.SoftwareVersion
+	}
+
+	// derive the v1 type from the capabilities of the light
+	result.Type = v2LightType(light, device)
+
+	// convert the state
+	if light.On != nil {
+		result.State.On = light.On.On
+	}
+
+	if light.Dimming != nil {
+		result.State.Brightness = brightnessFromPercent(light.Dimming.Brightness)
+	}
+
+	if light.Color != nil {
+		result.State.XY = []float64{light.Color.XY.X, light.Color.XY.Y}
+		result.State.ColorMode = "xy"
+		result.Capabilities = v2Capabilities(light.Color)
+	}
+
+	if light.ColorTemperature != nil && light.ColorTemperature.MirekValid {
+		result.State.ColorTemperature = light.ColorTemperature.Mirek
+		result.State.ColorMode = "ct"
+	}
+
+	// a light is reachable, if its device is connected
+	for _, connectivity := range b.connectivity {
+		if connectivity.Owner.RID == light.Owner.RID {
+			result.State.Reachable = connectivity.Status == "connected"
+
+			// the mac address only identifies the light, if its
+			// device has no other lights
+			if device != nil && countServices(device, "light") == 1 {
+				result.UniqueID = connectivity.MAC
+			}
+		}
+	}
+
+	return result
+}
+
+// convertGroup Convert a v2 room or zone into a group of the v1 api. The
+// mutex must be held by the caller.
+func (b *BridgeV2) convertGroup(group *v2Group) *Group {
+	result := &Group{
+		ID:     v1ID(group.IDv1, group.ID),
+		Name:   group.Metadata.Name,
+		Type:   v2GroupTypes[group.Type],
+		Class:  group.Metadata.Archetype,
+		Action: &State{},
+		State:  &GroupState{},
+	}
+
+	// collect the lights of the group. Rooms contain devices, zones
+	// contain the lights directly.
+	for _, child := range group.Children {
+		for _, light := range b.lights {
+			if light.ID == child.RID || light.Owner.RID == child.RID {
+				result.Lights = append(result.Lights, v1ID(light.IDv1, light.ID))
+			}
+		}
+	}
+
+	sort.Slice(result.Lights, func(i, j int) bool {
+		return lessID(result.Lights[i], result.Lights[j])
+	})
+
+	// add the state of the grouped light
+	if groupedLight, ok := b.groupedLights[b.groupedLightID(group.ID)]; ok {
+		if groupedLight.On != nil {
+			result.State.AnyOn = groupedLight.On.On
+			result.Action.On = groupedLight.On.On
+		}
+
+		if groupedLight.Dimming != nil {
+			result.Action.Brightness = brightnessFromPercent(groupedLight.Dimming.Brightness)
+		}
+	}
+
+	// all lights are on, if no light of the group is off
+	result.State.AllOn = result.State.AnyOn
+
+	for _, light := range b.lights {
+		if light.On == nil || light.On.On {
+			continue
+		}
+
+		for _, id := range result.Lights {
+			if id == v1ID(light.IDv1, light.ID) {
+				result.State.AllOn = false
+			}
+		}
+	}
+
+	return result
+}
+
+// convertSensor Convert a v2 sensor into a sensor of the v1 api. The
+// mutex must be held by the caller.
+func (b *BridgeV2) convertSensor(sensor *v2Sensor) *Sensor {
+	result := &Sensor{
+		ID:     v1ID(sensor.IDv1, sensor.ID),
+		Type:   v2SensorTypes[sensor.Type],
+		State:  &SensorState{},
+		Config: &SensorConfig{On: sensor.Enabled},
+	}
+
+	// add the product information of the device
+	if device, ok := b.devices[sensor.Owner.RID]; ok {
+		result.Name = device.Metadata.Name
+		result.ModelID = device.ProductData.ModelID
+		result.ManufacturerName = device.ProductData.ManufacturerName
+		result.SoftwareVersion = device.ProductData.SoftwareVersion
+	}
+
+	// a sensor is reachable, if its device is connected
+	for _, connectivity := range b.connectivity {
+		if connectivity.Owner.RID == sensor.Owner.RID {
+			result.Config.Reachable = connectivity.Status == "connected"
+			result.UniqueID = connectivity.MAC + "-" + sensor.Type
+		}
+	}
+
+	// convert the state
+	switch {
+	case sensor.Motion != nil:
+		result.State.Presence = sensor.Motion.Motion
+	case sensor.Temperature != nil:
+		result.State.Temperature = int(math.Round(sensor.Temperature.Temperature * 100))
+	case sensor.Light != nil:
+		result.State.LightLevel = sensor.Light.LightLevel
+	case sensor.ContactReport != nil:
+		result.State.Open = sensor.ContactReport.State == "no_contact"
+		result.State.LastUpdated = sensor.ContactReport.Changed
+	case sensor.Button != nil && sensor.Button.ButtonReport != nil:
+		result.State.ButtonEvent = sensor.Metadata.ControlID*1000 + v2ButtonEvents[sensor.Button.ButtonReport.Event]
+		result.State.LastUpdated = sensor.Button.ButtonReport.Updated
+	}
+
+	return result
+}
+
+// lookup Resolve a v2 id with the known resources. If the resource is
+// unknown, the given resource types are fetched once and the id is
+// resolved again.
+func (b *BridgeV2) lookup(resolve func() string, types ...string) (string, error) {
+	b.mutex.Lock()
+	rid := resolve()
+	b.mutex.Unlock()
+
+	// return the id of known resources
+	if rid != "" {
+		return rid, nil
+	}
+
+	// fetch the resources, as they may have been added
+	if err := b.refresh(types...); err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return resolve(), nil
+}
+
+// countServices Return the number of services of the given type, that
+// belong to the device
+func countServices(device *v2Device, rtype string) int {
+	count := 0
+
+	for _, service := range device.Services {
+		if service.RType == rtype {
+			count++
+		}
+	}
+
+	return count
+}
+
+// groupedLightID Return the id of the grouped light of a room or zone.
+// The mutex must be held by the caller.
+func (b *BridgeV2) groupedLightID(groupID string) string {
+	group, ok := b.groups[groupID]
+	if !ok {
+		return ""
+	}
+
+	for _, service := range group.Services {
+		if service.RType == "grouped_light" {
+			return service.RID
+		}
+	}
+
+	return ""
+}
+
+// groupID Return the v1 id of a room or zone. The mutex must be held by
+// the caller.
+func (b *BridgeV2) groupID(rid string) string {
+	if group, ok := b.groups[rid]; ok {
+		return v1ID(group.IDv1, group.ID)
+	}
+
+	return ""
+}
+
+// resourceID Return the v2 id of the resource with the given v1 id. The
+// mutex must be held by the caller.
+func (b *BridgeV2) resourceID(id string, rtypes ...string) string {
+	for _, rtype := range rtypes {
+		switch rtype {
+		case "light":
+			for rid, light := range b.lights {
+				if v1ID(light.IDv1, light.ID) == id {
+					return rid
+				}
+			}
+		case "room", "zone":
+			for rid, group := range b.groups {
+				if group.Type == rtype && v1ID(group.IDv1, group.ID) == id {
+					return rid
+				}
+			}
+		case "scene":
+			for rid, scene := range b.scenes {
+				if v1ID(scene.IDv1, scene.ID) == id {
+					return rid
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// get Perform a GET request against the v2 api of the bridge and
+// unmarshal the data of the response into the result
+func (b *BridgeV2) get(path string, result interface{}) error {
+	req, err := http.NewRequest("GET", "https://"+b.address+path, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return b.do(req, result)
+}
+
+// put Perform a PUT request with the json encoded body against the v2
+// api of the bridge
+func (b *BridgeV2) put(rtype, rid string, body interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		"PUT",
+		"https://"+b.address+"/clip/v2/resource/"+rtype+"/"+rid,
+		bytes.NewBuffer(bodyBytes),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return b.do(req, nil)
+}
+
+// do Perform the request with the application key and decode the
+// response
+func (b *BridgeV2) do(req *http.Request, result interface{}) error {
+	req.Header.Set("hue-application-key", b.username)
+
+	res, err := b.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return err
+	}
+
+	var response v2Response
+
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return err
+	}
+
+	// return the first error of the bridge
+	if len(response.Errors) > 0 {
+		return &APIError{Description: response.Errors[0].Description}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(response.Data, result)
+}
+
+// convertState Convert a state of the v1 api into the body of a v2 light
+// update. Hue and saturation are converted into a color within the gamut
+// of the light. Fields without equivalent in the v2 api are refused.
+func convertState(state *State, light *v2Light) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"on": v2On{On: state.On},
+	}
+
+	if state.Brightness > 0 {
+		body["dimming"] = v2Dimming{Brightness: float64(state.Brightness) * 100 / 254}
+	}
+
+	if state.ColorTemperature > 0 {
+		body["color_temperature"] = map[string]int{"mirek": state.ColorTemperature}
+	}
+
+	// xy wins over hue and saturation, like on the v1 api
+	if len(state.XY) == 2 {
+		body["color"] = v2Color{XY: v2XY{X: state.XY[0], Y: state.XY[1]}}
+	} else if state.Hue != nil || state.Saturation != nil {
+		point := v2ColorFromHS(state, light)
+		body["color"] = v2Color{XY: v2XY{X: point.X, Y: point.Y}}
+	}
+
+	switch state.Alert {
+	case "", "none":
+		// nothing to signal
+	case "select":
+		body["alert"] = v2Alert{Action: "breathe"}
+	default:
+		return nil, fmt.Errorf("%w: alert '%s'", ErrUnsupportedState, state.Alert)
+	}
+
+	switch state.Effect {
+	case "":
+		// keep the current effect
+	case "none":
+		body["effects"] = v2Effects{Effect: "no_effect"}
+	default:
+		return nil, fmt.Errorf("%w: effect '%s'", ErrUnsupportedState, state.Effect)
+	}
+
+	// the v2 api expects the duration in milliseconds
+	if state.TransitionTime != nil {
+		body["dynamics"] = v2Dynamics{Duration: *state.TransitionTime * 100}
+	}
+
+	return body, nil
+}
+
+// v2ColorFromHS Convert the hue and saturation of the state into a color
+// within the gamut of the light. A missing hue or saturation is taken from
+// the current color of the light.
+func v2ColorFromHS(state *State, light *v2Light) color.Point {
+	gamut := color.GamutDefault
+
+	var hue, saturation float64
+
+	if light != nil && light.Color != nil {
+		if capabilities := v2Capabilities(light.Color); capabilities != nil {
+			gamut = color.NewGamut(capabilities.Control.ColorGamutType, capabilities.Control.ColorGamut)
+		}
+
+		hue, saturation = color.ToHSV(color.Point{X: light.Color.XY.X, Y: light.Color.XY.Y}, gamut)
+	}
+
+	if state.Hue != nil {
+		hue = float64(*state.Hue) * 360 / 65535
+	}
+
+	if state.Saturation != nil {
+		saturation = float64(*state.Saturation) * 100 / 254
+	}
+
+	point := color.FromHSV(hue, saturation, gamut)
+
+	// the bridge only uses four decimals
+	return color.Point{
+		X: math.Round(point.X*10000) / 10000,
+		Y: math.Round(point.Y*10000) / 10000,
+	}
+}
+
+// v1ID Extract the id from a v1 resource path, e.g. /lights/3. Resources
+// without v1 id are identified by their v2 id.
+func v1ID(idV1, id string) string {
+	if idV1 == "" {
+		return id
+	}
+
+	return idV1[strings.LastIndex(idV1, "/")+1:]
+}
+
+// v2Capabilities Convert the color gamut of a v2 light into the
+// capabilities of the v1 api
+func v2Capabilities(color *v2Color) *Capabilities {
+	// older firmwares report no gamut
+	if color.Gamut == nil && color.GamutType == "" {
+		return nil
+	}
+
+	control := &Control{ColorGamutType: color.GamutType}
+
+	if gamut := color.Gamut; gamut != nil {
+		control.ColorGamut = [][]float64{
+			{gamut.Red.X, gamut.Red.Y},
+			{gamut.Green.X, gamut.Green.Y},
+			{gamut.Blue.X, gamut.Blue.Y},
+		}
+	}
+
+	return &Capabilities{Control: control}
+}
+
+// v2LightType Derive the type of the v1 api from the capabilities of
+// a light
+func v2LightType(light *v2Light, device *v2Device) string {
+	switch {
+	case device != nil && device.ProductData.ProductArchetype == "plug":
+		return "On/Off plug-in unit"
+	case light.Color != nil && light.ColorTemperature != nil:
+		return "Extended color light"
+	case light.Color != nil:
+		return "Color light"
+	case light.ColorTemperature != nil:
+		return "Color temperature light"
+	case light.Dimming != nil:
+		return "Dimmable light"
+	}
+
+	return "On/Off light"
+}
+
+// brightnessFromPercent Convert the v2 brightness (0 - 100 [%]) into the
+// v1 brightness (1 - 254)
+func brightnessFromPercent(brightness float64) int {
+	return int(math.Max(1, math.Round(brightness*254/100)))
+}
@@ -0,0 +1,391 @@
+package hue
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testV2Resources = map[string]string{
+	"device": `[
+  {"id": "d1", "product_data": {"model_id": "LCT015", "manufacturer_name": "Signify Netherlands B.V.", "product_archetype": "sultan_bulb", "software_version": "1.50.2"}, "metadata": {"name": "Ceiling"}},
+  {"id": "d2", "product_data": {"model_id": "Plug 01", "manufacturer_name": "OSRAM", "product_archetype": "plug", "software_version": "1.04.12"}, "metadata": {"name": "Fan"}},
+  {"id": "d3", "product_data": {"model_id": "RWL021", "manufacturer_name": "Signify Netherlands B.V.", "software_version": "6.1.1"}, "metadata": {"name": "Dimmer"}}
+]`,
+	"zigbee_connectivity": `[
+  {"id": "z1", "owner": {"rid": "d1", "rtype": "device"}, "status": "connected", "mac_address": "00:17:88:01:00:00:00:01"},
+  {"id": "z2", "owner": {"rid": "d2", "rtype": "device"}, "status": "connectivity_issue", "mac_address": "00:17:88:01:00:00:00:02"},
+  {"id": "z3", "owner": {"rid": "d3", "rtype": "device"}, "status": "connected", "mac_address": "00:17:88:01:00:00:00:03"}
+]`,
+	"light": `[
+  {"id": "l1", "id_v1": "/lights/1", "owner": {"rid": "d1", "rtype": "device"}, "on": {"on": true}, "dimming": {"brightness": 50}, "color_temperature": {"mirek": 366, "mirek_valid": true}, "color": {"xy": {"x": 0.4573, "y": 0.41}, "gamut": {"red": {"x": 0.6915, "y": 0.3083}, "green": {"x": 0.17, "y": 0.7}, "blue": {"x": 0.1532, "y": 0.0475}}, "gamut_type": "C"}},
+  {"id": "l2", "id_v1": "/lights/2", "owner": {"rid": "d2", "rtype": "device"}, "on": {"on": false}}
+]`,
+	"room": `[
+  {"id": "r1", "id_v1": "/groups/1", "metadata": {"name": "Living room", "archetype": "living_room"}, "children": [{"rid": "d1", "rtype": "device"}, {"rid": "d2", "rtype": "device"}], "services": [{"rid": "g1", "rtype": "grouped_light"}]}
+]`,
+	"zone":          `[]`,
+	"grouped_light": `[{"id": "g1", "id_v1": "/groups/1", "owner": {"rid": "r1", "rtype": "room"}, "on": {"on": true}, "dimming": {"brightness": 100}}]`,
+	"scene":         `[{"id": "s1", "id_v1": "/scenes/dE1yU5bW9pQ4a2x", "metadata": {"name": "Relax"}, "group": {"rid": "r1", "rtype": "room"}, "actions": [{"target": {"rid": "l1", "rtype": "light"}}]}]`,
+	"motion":        `[]`,
+	"temperature":   `[]`,
+	"light_level":   `[]`,
+	"contact":       `[]`,
+	"button": `[
+  {"id": "b1", "id_v1": "/sensors/5", "owner": {"rid": "d3", "rtype": "device"}, "metadata": {"control_id": 1}, "button": {"button_report": {"updated": "2023-01-01T10:00:00.000Z", "event": "short_release"}}},
+  {"id": "b4", "id_v1": "/sensors/5", "owner": {"rid": "d3", "rtype": "device"}, "metadata": {"control_id": 4}, "button": {"button_report": {"updated": "2023-01-01T10:05:00.000Z", "event": "long_release"}}}
+]`,
+}
+
+func testServerV2(puts map[string]string, events chan string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("hue-application-key") != "success" {
+			w.WriteHeader(403)
+			w.Write([]byte(`{"errors": [{"description": "unauthorized user"}], "data": []}`))
+			return
+		}
+
+		if r.URL.Path == "/eventstream/clip/v2" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(200)
+			w.(http.Flusher).Flush()
+
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-events:
+					w.Write([]byte(": hi\n\nid: 1:0\ndata: " + event + "\n\n"))
+					w.(http.Flusher).Flush()
+				}
+			}
+		}
+
+		rtype := strings.TrimPrefix(r.URL.Path, "/clip/v2/resource/")
+
+		if r.Method == "PUT" {
+			bodyBytes, _ := io.ReadAll(r.Body)
+			puts[rtype] = string(bodyBytes)
+
+			w.Write([]byte(`{"errors": [], "data": [{"rid": "x", "rtype": "light"}]}`))
+			return
+		}
+
+		data, ok := testV2Resources[rtype]
+		if !ok {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"errors": [{"description": "resource not found"}], "data": []}`))
+			return
+		}
+
+		w.Write([]byte(`{"errors": [], "data": ` + data + `}`))
+	}))
+}
+
+func TestBridgeV2_Lights(t *testing.T) {
+	mockServer := testServerV2(map[string]string{}, nil)
+	defer mockServer.Close()
+
+	bridge := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "success", mockServer.Client())
+
+	lights, err := bridge.Lights()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Light{
+		{
+			ID:               "1",
+			Type:             "Extended color light",
+			Name:             "Ceiling",
+			ModelID:          "LCT015",
+			ManufacturerName: "Signify Netherlands B.V.",
+			SoftwareVersion:  "1.50.2",
+			State:            &State{On: true, Brightness: 127, XY: []float64{0.4573, 0.41}, ColorTemperature: 366, ColorMode: "ct", Reachable: true},
+			Capabilities: &Capabilities{Control: &Control{
+				ColorGamutType: "C",
+				ColorGamut:     [][]float64{{0.6915, 0.3083}, {0.17, 0.7}, {0.1532, 0.0475}},
+			}},
+		},
+		{
+			ID:               "2",
+			Type:             "On/Off plug-in unit",
+			Name:             "Fan",
+			ModelID:          "Plug 01",
+			ManufacturerName: "OSRAM",
+			SoftwareVersion:  "1.04.12",
+			State:            &State{On: false, Reachable: false},
+		},
+	}, lights)
+
+	// use an unknown application key
+	unknown := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "unknown", mockServer.Client())
+
+	_, err = unknown.Lights()
+	assert.EqualError(t, err, "unauthorized user")
+}
+
+func TestBridgeV2_Groups(t *testing.T) {
+	mockServer := testServerV2(map[string]string{}, nil)
+	defer mockServer.Close()
+
+	bridge := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "success", mockServer.Client())
+
+	group, err := bridge.Group("1")
+	assert.Nil(t, err)
+	assert.Equal(t, &Group{
+		ID:     "1",
+		Name:   "Living room",
+		Type:   "Room",
+		Class:  "living_room",
+		Lights: []string{"1", "2"},
+		Action: &State{On: true, Brightness: 254},
+		State:  &GroupState{AllOn: false, AnyOn: true},
+	}, group)
+
+	scenes, err := bridge.Scenes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Scene{
+		{ID: "dE1yU5bW9pQ4a2x", Name: "Relax", Type: "GroupScene", Group: "1", Lights: []string{"1"}},
+	}, scenes)
+}
+
+func TestBridgeV2_Sensors(t *testing.T) {
+	mockServer := testServerV2(map[string]string{}, nil)
+	defer mockServer.Close()
+
+	bridge := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "success", mockServer.Client())
+
+	// the buttons of the dimmer switch are merged into one sensor,
+	// that reports the last pressed button
+	sensors, err := bridge.Sensors()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Sensor{
+		{
+			ID:               "5",
+			Type:             SensorTypeZLLSwitch,
+			Name:             "Dimmer",
+			ModelID:          "RWL021",
+			ManufacturerName: "Signify Netherlands B.V.",
+			SoftwareVersion:  "6.1.1",
+			UniqueID:         "00:17:88:01:00:00:00:03-button",
+			State:            &SensorState{ButtonEvent: 4003, LastUpdated: "2023-01-01T10:05:00.000Z"},
+			Config:           &SensorConfig{Reachable: true},
+		},
+	}, sensors)
+}
+
+func TestBridgeV2_Update(t *testing.T) {
+	puts := map[string]string{}
+
+	mockServer := testServerV2(puts, nil)
+	defer mockServer.Close()
+
+	bridge := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "success", mockServer.Client())
+
+	// unknown resources cannot be updated
+	err := bridge.LightUpdateState(&Light{ID: "42"}, &State{On: true})
+	assert.EqualError(t, err, "light 42 not found")
+
+	tests := []struct {
+		description   string
+		update        func() error
+		resource      string
+		expectedError error
+		expectedBody  string
+	}{
+		{
+			description: "light",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Brightness: 127, ColorTemperature: 250})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"color_temperature":{"mirek":250},"dimming":{"brightness":50},"on":{"on":true}}`,
+		},
+		{
+			description: "light with transition time",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, TransitionTime: TransitionTime(2 * time.Second)})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"dynamics":{"duration":2000},"on":{"on":true}}`,
+		},
+		{
+			description: "light with hue and saturation",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Hue: Int(0), Saturation: Int(254)})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"color":{"xy":{"x":0.6915,"y":0.3083}},"on":{"on":true}}`,
+		},
+		{
+			description: "light with hue",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Hue: Int(43690)})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"color":{"xy":{"x":0.2046,"y":0.1466}},"on":{"on":true}}`,
+		},
+		{
+			description: "light with saturation",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Saturation: Int(0)})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"color":{"xy":{"x":0.3227,"y":0.329}},"on":{"on":true}}`,
+		},
+		{
+			description: "light with alert",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Alert: "select"})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"alert":{"action":"breathe"},"on":{"on":true}}`,
+		},
+		{
+			description: "light with unsupported alert",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "2"}, &State{On: true, Alert: "lselect"})
+			},
+			resource:      "light/l2",
+			expectedError: ErrUnsupportedState,
+		},
+		{
+			description: "light with effect",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "1"}, &State{On: true, Effect: "none"})
+			},
+			resource:     "light/l1",
+			expectedBody: `{"effects":{"effect":"no_effect"},"on":{"on":true}}`,
+		},
+		{
+			description: "light with unsupported effect",
+			update: func() error {
+				return bridge.LightUpdateState(&Light{ID: "2"}, &State{On: true, Effect: "colorloop"})
+			},
+			resource:      "light/l2",
+			expectedError: ErrUnsupportedState,
+		},
+		{
+			description: "group",
+			update: func() error {
+				return bridge.GroupUpdateAction(&Group{ID: "1"}, &State{On: false, ColorTemperature: 250})
+			},
+			resource:     "grouped_light/g1",
+			expectedBody: `{"on":{"on":false}}`,
+		},
+		{
+			description: "scene",
+			update: func() error {
+				return bridge.RecallScene(&Scene{ID: "dE1yU5bW9pQ4a2x"})
+			},
+			resource:     "scene/s1",
+			expectedBody: `{"recall":{"action":"active"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.update()
+
+		assert.ErrorIsf(t, err, test.expectedError, test.description)
+		assert.Equalf(t, test.expectedBody, puts[test.resource], test.description)
+	}
+}
+
+func TestBridgeV2_Subscribe(t *testing.T) {
+	events := make(chan string)
+
+	mockServer := testServerV2(map[string]string{}, events)
+	defer mockServer.Close()
+
+	bridge := newBridgeV2(strings.TrimPrefix(mockServer.URL, "https://"), "success", mockServer.Client())
+
+	stop := make(chan struct{})
+
+	subscription, err := bridge.Subscribe(stop)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description   string
+		event         string
+		expectedEvent *Event
+	}{
+		{
+			description: "light turned off",
+			event:       `[{"type": "update", "data": [{"id": "l1", "type": "light", "on": {"on": false}}]}]`,
+			expectedEvent: &Event{Light: &Light{
+				ID:               "1",
+				Type:             "Extended color light",
+				Name:             "Ceiling",
+				ModelID:          "LCT015",
+				ManufacturerName: "Signify Netherlands B.V.",
+				SoftwareVersion:  "1.50.2",
+				State:            &State{On: false, Brightness: 127, XY: []float64{0.4573, 0.41}, ColorTemperature: 366, ColorMode: "ct", Reachable: true},
+				Capabilities: &Capabilities{Control: &Control{
+					ColorGamutType: "C",
+					ColorGamut:     [][]float64{{0.6915, 0.3083}, {0.17, 0.7}, {0.1532, 0.0475}},
+				}},
+			}},
+		},
+		{
+			description: "plug connected",
+			event:       `[{"type": "update", "data": [{"id": "z2", "type": "zigbee_connectivity", "status": "connected"}]}]`,
+			expectedEvent: &Event{Light: &Light{
+				ID:               "2",
+				Type:             "On/Off plug-in unit",
+				Name:             "Fan",
+				ModelID:          "Plug 01",
+				ManufacturerName: "OSRAM",
+				SoftwareVersion:  "1.04.12",
+				State:            &State{On: false, Reachable: true},
+			}},
+		},
+		{
+			description: "room dimmed",
+			event:       `[{"type": "update", "data": [{"id": "g1", "type": "grouped_light", "dimming": {"brightness": 10}}]}]`,
+			expectedEvent: &Event{Group: &Group{
+				ID:     "1",
+				Name:   "Living room",
+				Type:   "Room",
+				Class:  "living_room",
+				Lights: []string{"1", "2"},
+				Action: &State{On: true, Brightness: 25},
+				State:  &GroupState{AllOn: false, AnyOn: true},
+			}},
+		},
+		{
+			description: "button pressed",
+			event:       `[{"type": "update", "data": [{"id": "b1", "type": "button", "button": {"button_report": {"updated": "2023-01-01T11:00:00.000Z", "event": "initial_press"}}}]}]`,
+			expectedEvent: &Event{Sensor: &Sensor{
+				ID:               "5",
+				Type:             SensorTypeZLLSwitch,
+				Name:             "Dimmer",
+				ModelID:          "RWL021",
+				ManufacturerName: "Signify Netherlands B.V.",
+				SoftwareVersion:  "6.1.1",
+				UniqueID:         "00:17:88:01:00:00:00:03-button",
+				State:            &SensorState{ButtonEvent: 1000, LastUpdated: "2023-01-01T11:00:00.000Z"},
+				Config:           &SensorConfig{Reachable: true},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		events <- test.event
+
+		select {
+		case event := <-subscription:
+			assert.Equalf(t, test.expectedEvent, event, test.description)
+		case <-time.After(time.Second):
+			t.Errorf("%s: no event received", test.description)
+		}
+	}
+
+	// the subscription ends, once stop is closed
+	close(stop)
+
+	_, ok := <-subscription
+	assert.False(t, ok)
+}
@@ -0,0 +1,277 @@
+package hue
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MDNSAddress Multicast address of mDNS
+	MDNSAddress = "224.0.0.251:5353"
+
+	// SSDPAddress Multicast address of SSDP
+	SSDPAddress = "239.255.255.250:1900"
+
+	// mdnsService Service, that hue bridges announce via mDNS
+	mdnsService = "_hue._tcp.local."
+
+	// ssdpRequest Search request for all upnp devices. Hue bridges
+	// answer it with their bridge id in the hue-bridgeid header.
+	ssdpRequest = "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + SSDPAddress + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n" +
+		"ST: urn:schemas-upnp-org:device:basic:1\r\n\r\n"
+)
+
+// DiscoveredBridge A bridge, that was found in the local network
+type DiscoveredBridge struct {
+	ID      string
+	Address string
+}
+
+// Discovery Finds bridges in the local network via mDNS and SSDP
+type Discovery struct {
+	MDNSAddress string
+	SSDPAddress string
+	Timeout     time.Duration
+}
+
+// NewDiscovery Create a new discovery, that waits for answers of the
+// bridges until the timeout expired
+func NewDiscovery(timeout time.Duration) *Discovery {
+	return &Discovery{
+		MDNSAddress: MDNSAddress,
+		SSDPAddress: SSDPAddress,
+		Timeout:     timeout,
+	}
+}
+
+// Discover Query the local network via mDNS and SSDP in parallel and
+// return all found bridges, sorted by their id
+func (d *Discovery) Discover() ([]*DiscoveredBridge, error) {
+	var wg sync.WaitGroup
+
+	var mdnsBridges, ssdpBridges []*DiscoveredBridge
+	var mdnsErr, ssdpErr error
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		mdnsBridges, mdnsErr = d.query(d.MDNSAddress, mdnsRequest(), parseMDNSResponse)
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		ssdpBridges, ssdpErr = d.query(d.SSDPAddress, []byte(ssdpRequest), parseSSDPResponse)
+	}()
+
+	wg.Wait()
+
+	// only fail, if no protocol could be used
+	if mdnsErr != nil && ssdpErr != nil {
+		return nil, errors.Join(mdnsErr, ssdpErr)
+	}
+
+	// merge the bridges by their id. mDNS is preferred, as it also
+	// contains the port of the api.
+	bridgeMap := map[string]*DiscoveredBridge{}
+
+	for _, bridge := range append(ssdpBridges, mdnsBridges...) {
+		bridgeMap[bridge.ID] = bridge
+	}
+
+	var bridges []*DiscoveredBridge
+
+	for _, bridge := range bridgeMap {
+		log.WithFields(log.Fields{
+			"id":      bridge.ID,
+			"address": bridge.Address,
+		}).Debug("discovered bridge")
+
+		bridges = append(bridges, bridge)
+	}
+
+	sort.Slice(bridges, func(i, j int) bool {
+		return bridges[i].ID < bridges[j].ID
+	})
+
+	return bridges, nil
+}
+
+// query Send the request to the given address and parse all answers,
+// that arrive until the timeout expired
+func (d *Discovery) query(address string, request []byte, parse func([]byte, *net.UDPAddr) []*DiscoveredBridge) ([]*DiscoveredBridge, error) {
+	target, err := net.ResolveUDPAddr("udp4", address)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	// listen on a random port. Responders answer queries from other
+	// ports than the multicast port directly to the sender.
+	conn, err := net.ListenUDP("udp4", nil)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(request, target); err != nil {
+		return nil, err
+	}
+
+	// stop reading, once the timeout expired
+	if err := conn.SetReadDeadline(time.Now().Add(d.Timeout)); err != nil {
+		return nil, err
+	}
+
+	var bridges []*DiscoveredBridge
+
+	buffer := make([]byte, 65536)
+
+	for {
+		n, source, err := conn.ReadFromUDP(buffer)
+
+		// the timeout ends the discovery
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return bridges, nil
+		}
+
+		// error handling
+		if err != nil {
+			return bridges, err
+		}
+
+		bridges = append(bridges, parse(buffer[:n], source)...)
+	}
+}
+
+// mdnsRequest Create the mDNS query for the hue service
+func mdnsRequest() []byte {
+	msg := new(dns.Msg)
+	msg.SetQuestion(mdnsService, dns.TypePTR)
+	msg.RecursionDesired = false
+
+	// the message is static, so packing cannot fail
+	request, _ := msg.Pack()
+
+	return request
+}
+
+// parseMDNSResponse Return the bridges, that are announced in the mDNS
+// response
+func parseMDNSResponse(payload []byte, source *net.UDPAddr) []*DiscoveredBridge {
+	var msg dns.Msg
+
+	if err := msg.Unpack(payload); err != nil {
+		return nil
+	}
+
+	var instances []string
+
+	services := map[string]*dns.SRV{}
+	ids := map[string]string{}
+	hosts := map[string]net.IP{}
+
+	// collect the records of all sections
+	for _, record := range append(msg.Answer, msg.Extra...) {
+		switch record := record.(type) {
+		case *dns.PTR:
+			if strings.EqualFold(record.Hdr.Name, mdnsService) {
+				instances = append(instances, record.Ptr)
+			}
+		case *dns.SRV:
+			services[record.Hdr.Name] = record
+		case *dns.TXT:
+			for _, txt := range record.Txt {
+				if strings.HasPrefix(txt, "bridgeid=") {
+					ids[record.Hdr.Name] = strings.ToLower(strings.TrimPrefix(txt, "bridgeid="))
+				}
+			}
+		case *dns.A:
+			hosts[record.Hdr.Name] = record.A
+		}
+	}
+
+	var bridges []*DiscoveredBridge
+
+	for _, instance := range instances {
+		// the bridge id is required for finding the bridge again
+		id, ok := ids[instance]
+		if !ok {
+			continue
+		}
+
+		// use the sender as address, unless the host is announced
+		host := source.IP.String()
+		port := 443
+
+		if service, ok := services[instance]; ok {
+			port = int(service.Port)
+
+			if ip, ok := hosts[service.Target]; ok {
+				host = ip.String()
+			}
+		}
+
+		bridges = append(bridges, &DiscoveredBridge{
+			ID:      id,
+			Address: joinAddress(host, port),
+		})
+	}
+
+	return bridges
+}
+
+// parseSSDPResponse Return the bridge, that answered the SSDP request.
+// Other upnp devices are ignored.
+func parseSSDPResponse(payload []byte, source *net.UDPAddr) []*DiscoveredBridge {
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(payload)), nil)
+
+	if err != nil {
+		return nil
+	}
+
+	defer res.Body.Close()
+
+	// only hue bridges send their id
+	id := res.Header.Get("hue-bridgeid")
+	if id == "" {
+		return nil
+	}
+
+	return []*DiscoveredBridge{
+		{
+			ID:      strings.ToLower(id),
+			Address: source.IP.String(),
+		},
+	}
+}
+
+// joinAddress Join the host and port. The default https port is omitted.
+func joinAddress(host string, port int) string {
+	if port == 443 || port == 0 {
+		return host
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,237 @@
+package hue
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+// testResponder Stand-in for a multicast responder, that answers every
+// query on a local port with the given responses
+func testResponder(t *testing.T, responses ...func(query []byte) []byte) (string, func()) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buffer := make([]byte, 65536)
+
+		for {
+			n, source, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+
+			for _, response := range responses {
+				conn.WriteToUDP(response(buffer[:n]), source)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+// testMDNSResponse Answer a mDNS query like a hue bridge
+func testMDNSResponse(id string, ip net.IP, port uint16) func([]byte) []byte {
+	return func(query []byte) []byte {
+		var request dns.Msg
+		request.Unpack(query)
+
+		instance := "Hue Bridge - " + id[10:] + "." + mdnsService
+		host := "001788" + id[10:] + ".local."
+
+		response := new(dns.Msg)
+		response.SetReply(&request)
+		response.Answer = []dns.RR{
+			&dns.PTR{Hdr: dns.RR_Header{Name: mdnsService, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 120}, Ptr: instance},
+		}
+		response.Extra = []dns.RR{
+			&dns.SRV{Hdr: dns.RR_Header{Name: instance, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 120}, Target: host, Port: port},
+			&dns.TXT{Hdr: dns.RR_Header{Name: instance, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 120}, Txt: []string{"bridgeid=" + id, "modelid=BSB002"}},
+			&dns.A{Hdr: dns.RR_Header{Name: host, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 120}, A: ip},
+		}
+
+		payload, _ := response.Pack()
+
+		return payload
+	}
+}
+
+// testSSDPResponse Answer a SSDP search like a hue bridge
+func testSSDPResponse(id string) func([]byte) []byte {
+	return func([]byte) []byte {
+		return []byte("HTTP/1.1 200 OK\r\n" +
+			"CACHE-CONTROL: max-age=100\r\n" +
+			"LOCATION: http://127.0.0.1:80/description.xml\r\n" +
+			"SERVER: Linux/3.14.0 UPnP/1.0 IpBridge/1.48.0\r\n" +
+			"hue-bridgeid: " + id + "\r\n" +
+			"ST: urn:schemas-upnp-org:device:basic:1\r\n\r\n")
+	}
+}
+
+func TestDiscovery_Discover(t *testing.T) {
+	mdnsAddress, closeMDNS := testResponder(t, testMDNSResponse("001788fffe100001", net.IPv4(192, 168, 1, 2), 443))
+	defer closeMDNS()
+
+	ssdpAddress, closeSSDP := testResponder(
+		t,
+		testSSDPResponse("001788FFFE100001"),
+		testSSDPResponse("001788FFFE100002"),
+		func([]byte) []byte { return []byte("HTTP/1.1 200 OK\r\nSERVER: a router\r\n\r\n") },
+	)
+	defer closeSSDP()
+
+	tests := []struct {
+		description    string
+		mdnsAddress    string
+		ssdpAddress    string
+		expectedError  bool
+		expectedResult []*DiscoveredBridge
+	}{
+		{
+			description:   "mdns and ssdp",
+			mdnsAddress:   mdnsAddress,
+			ssdpAddress:   ssdpAddress,
+			expectedError: false,
+			expectedResult: []*DiscoveredBridge{
+				{ID: "001788fffe100001", Address: "192.168.1.2"},
+				{ID: "001788fffe100002", Address: "127.0.0.1"},
+			},
+		},
+		{
+			description:   "mdns only",
+			mdnsAddress:   mdnsAddress,
+			ssdpAddress:   "invalid",
+			expectedError: false,
+			expectedResult: []*DiscoveredBridge{
+				{ID: "001788fffe100001", Address: "192.168.1.2"},
+			},
+		},
+		{
+			description:    "no protocol available",
+			mdnsAddress:    "invalid",
+			ssdpAddress:    "invalid",
+			expectedError:  true,
+			expectedResult: nil,
+		},
+	}
+
+	for _, test := range tests {
+		discovery := &Discovery{
+			MDNSAddress: test.mdnsAddress,
+			SSDPAddress: test.ssdpAddress,
+			Timeout:     200 * time.Millisecond,
+		}
+
+		result, err := discovery.Discover()
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+		assert.Equalf(t, test.expectedResult, result, test.description)
+	}
+}
+
+func TestLocator_Address(t *testing.T) {
+	mdnsAddress, closeMDNS := testResponder(
+		t,
+		testMDNSResponse("001788fffe100001", net.IPv4(192, 168, 1, 2), 443),
+		testMDNSResponse("001788fffe100002", net.IPv4(192, 168, 1, 3), 443),
+	)
+	defer closeMDNS()
+
+	discovery := &Discovery{
+		MDNSAddress: mdnsAddress,
+		SSDPAddress: "invalid",
+		Timeout:     200 * time.Millisecond,
+	}
+
+	tests := []struct {
+		description     string
+		address         string
+		bridgeID        string
+		expectedError   bool
+		expectedAddress string
+	}{
+		{
+			description:     "configured address",
+			address:         "10.0.0.2",
+			expectedError:   false,
+			expectedAddress: "10.0.0.2",
+		},
+		{
+			description:     "saved bridge id",
+			bridgeID:        "001788fffe100002",
+			expectedError:   false,
+			expectedAddress: "192.168.1.3",
+		},
+		{
+			description:   "unknown bridge id",
+			bridgeID:      "001788fffe100003",
+			expectedError: true,
+		},
+		{
+			description:   "multiple bridges without bridge id",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		memory := store.NewMemory(nil)
+
+		if test.bridgeID != "" {
+			assert.Nilf(t, memory.Set(bridgeIDKey, test.bridgeID), test.description)
+		}
+
+		address, err := NewLocator(test.address, discovery, memory).Address()
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+		assert.Equalf(t, test.expectedAddress, address, test.description)
+	}
+}
+
+func TestLocator_DialContext(t *testing.T) {
+	// the bridge got a new port, as the loopback has only one address
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	mdnsAddress, closeMDNS := testResponder(t, testMDNSResponse("001788fffe100001", net.IPv4(127, 0, 0, 1), uint16(port)))
+	defer closeMDNS()
+
+	memory := store.NewMemory(nil)
+
+	locator := NewLocator("", &Discovery{
+		MDNSAddress: mdnsAddress,
+		SSDPAddress: "invalid",
+		Timeout:     200 * time.Millisecond,
+	}, memory)
+
+	// the bridge was reachable on a port, that is closed now
+	closed, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locator.address = closed.Addr().String()
+	closed.Close()
+
+	conn, err := locator.DialContext(context.Background(), "tcp4", "bridge:443")
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:"+strconv.Itoa(port), conn.RemoteAddr().String())
+	bridgeID, err := memory.Get(bridgeIDKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "001788fffe100001", bridgeID)
+
+	conn.Close()
+}
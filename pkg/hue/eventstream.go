@@ -0,0 +1,261 @@
+package hue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSubscriptionNotSupported Returned by Subscribe, if the bridge cannot
+// push state changes
+var ErrSubscriptionNotSupported = errors.New("subscription not supported")
+
+const (
+	// eventStreamMinBackoff Delay before the first reconnect to the
+	// event stream
+	eventStreamMinBackoff = time.Second
+
+	// eventStreamMaxBackoff Maximum delay between two reconnects to
+	// the event stream
+	eventStreamMaxBackoff = 30 * time.Second
+)
+
+// Event A state change pushed by the bridge. Exactly one of the fields
+// is set and contains the complete, updated resource.
+type Event struct {
+	Light  *Light
+	Group  *Group
+	Sensor *Sensor
+}
+
+// Subscriber Implemented by bridges, that push state changes instead of
+// being polled
+type Subscriber interface {
+	Subscribe(stop <-chan struct{}) (<-chan *Event, error)
+}
+
+type v2Event struct {
+	Type string            `json:"type"`
+	Data []json.RawMessage `json:"data"`
+}
+
+// Subscribe Consume the event stream of the bridge until stop is closed.
+// The connection is reestablished with a backoff, if it breaks. The
+// returned channel is closed after stop was closed.
+func (b *BridgeV2) Subscribe(stop <-chan struct{}) (<-chan *Event, error) {
+	// fetch the current state of all resources, such that partial
+	// updates from the stream can be applied onto them
+	if err := b.refresh(
+		"device", "zigbee_connectivity", "light", "room", "zone", "grouped_light",
+		"motion", "temperature", "light_level", "contact", "button",
+	); err != nil {
+		return nil, err
+	}
+
+	// cancel the stream, once stop is closed
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// connect once in order to report unsupported bridges to the caller
+	res, err := b.connectEventStream(ctx)
+
+	// error handling
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	events := make(chan *Event)
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	go func() {
+		defer close(events)
+
+		backoff := eventStreamMinBackoff
+
+		for {
+			// read the stream until it breaks
+			if res != nil {
+				err = b.readEventStream(ctx, res, events)
+				res = nil
+
+				// the stream worked, so reset the backoff
+				backoff = eventStreamMinBackoff
+			}
+
+			// stop consuming, if the stream was cancelled
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Warnf("event stream interrupted, reconnecting in %s: %v", backoff, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			// increase the backoff for the next failed attempt
+			backoff *= 2
+			if backoff > eventStreamMaxBackoff {
+				backoff = eventStreamMaxBackoff
+			}
+
+			res, err = b.connectEventStream(ctx)
+		}
+	}()
+
+	return events, nil
+}
+
+// connectEventStream Open the event stream of the bridge
+func (b *BridgeV2) connectEventStream(ctx context.Context) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+b.address+"/eventstream/clip/v2", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("hue-application-key", b.username)
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := b.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the stream is only available with the correct status
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+
+		return nil, fmt.Errorf("cannot open event stream: %s", res.Status)
+	}
+
+	return res, nil
+}
+
+// readEventStream Read the server-sent events from the response and
+// publish the changed resources until the stream ends
+func (b *BridgeV2) readEventStream(ctx context.Context, res *http.Response, events chan<- *Event) error {
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// only data lines contain events. Ids, comments and empty
+		// lines are ignored.
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		for _, event := range b.parseEvents([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:")))) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	// a stream, that ended without error, is reported as well, as
+	// the bridge should never close it
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return errors.New("event stream closed")
+}
+
+// parseEvents Apply the updates of a data line to the known resources
+// and return the changed resources
+func (b *BridgeV2) parseEvents(data []byte) []*Event {
+	var messages []v2Event
+
+	if err := json.Unmarshal(data, &messages); err != nil {
+		log.Debugf("cannot parse event: %v", err)
+
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var events []*Event
+
+	for _, message := range messages {
+		// only updates of existing and added resources are handled
+		if message.Type != "update" && message.Type != "add" {
+			continue
+		}
+
+		for _, raw := range message.Data {
+			var ref v2Reference
+
+			if err := json.Unmarshal(raw, &struct {
+				ID   *string `json:"id"`
+				Type *string `json:"type"`
+			}{&ref.RID, &ref.RType}); err != nil {
+				continue
+			}
+
+			// merge the update into the known resource
+			b.apply(ref.RType, raw)
+
+			events = append(events, b.events(ref)...)
+		}
+	}
+
+	return events
+}
+
+// events Create the events for the changed resource. The mutex must be
+// held by the caller.
+func (b *BridgeV2) events(ref v2Reference) []*Event {
+	switch ref.RType {
+	case "light":
+		return []*Event{{Light: b.convertLight(b.lights[ref.RID])}}
+	case "zigbee_connectivity":
+		// the connectivity is the reachability of all lights of the
+		// device
+		connectivity := b.connectivity[ref.RID]
+
+		var events []*Event
+
+		for _, light := range b.lights {
+			if light.Owner.RID == connectivity.Owner.RID {
+				events = append(events, &Event{Light: b.convertLight(light)})
+			}
+		}
+
+		return events
+	case "grouped_light":
+		// search the room or zone of the grouped light
+		for id, group := range b.groups {
+			if b.groupedLightID(id) == ref.RID {
+				return []*Event{{Group: b.convertGroup(group)}}
+			}
+		}
+	default:
+		if sensor, ok := b.sensors[ref.RID]; ok {
+			return []*Event{{Sensor: b.convertSensor(sensor)}}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package hue
+
+import (
+	"sort"
+)
+
+// Group Represents a room, zone or light group at the hue bridge
+type Group struct {
+	ID     string
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Class  string      `json:"class"`
+	Lights []string    `json:"lights"`
+	Action *State      `json:"action"`
+	State  *GroupState `json:"state"`
+}
+
+// GroupState Represents the combined power state of all lights in
+// a group
+type GroupState struct {
+	AllOn bool `json:"all_on"`
+	AnyOn bool `json:"any_on"`
+}
+
+// Groups Query and return all groups
+func (b *Bridge) Groups() ([]*Group, error) {
+	// allocate the structure for the response body in memory
+	var groupMap map[string]*Group
+
+	// perform the api request to fetch all groups
+	if err := b.get("/groups", &groupMap); err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+
+	for id, group := range groupMap {
+		// add the ID to the group
+		group.ID = id
+
+		groups = append(groups, group)
+	}
+
+	// sort the groups by their id in order to always return them
+	// in the same order
+	sort.Slice(groups, func(i, j int) bool {
+		return lessID(groups[i].ID, groups[j].ID)
+	})
+
+	return groups, nil
+}
+
+// Group Query and return a group by its id
+func (b *Bridge) Group(id string) (*Group, error) {
+	// allocate the structure for the response body in memory
+	var group Group
+
+	// perform the api request to fetch the group
+	if err := b.get("/groups/"+id, &group); err != nil {
+		return nil, err
+	}
+
+	// add the ID to the group
+	group.ID = id
+
+	return &group, nil
+}
+
+// GroupUpdateAction Update the state of all lights in a group with
+// a single request
+func (b *Bridge) GroupUpdateAction(group *Group, action *State) error {
+	return b.put("/groups/"+group.ID+"/action", action)
+}
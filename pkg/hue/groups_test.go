@@ -0,0 +1,127 @@
+package hue
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func testServerGroups() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" && strings.HasSuffix(r.RequestURI, "/groups/1/action") {
+			var action State
+
+			bodyBytes, _ := io.ReadAll(r.Body)
+
+			if err := json.Unmarshal(bodyBytes, &action); err != nil {
+				w.WriteHeader(200)
+				w.Write([]byte(`[{"error": {"type": 2, "address": "/groups/1/action", "description": "body contains invalid json"}}]`))
+				return
+			}
+
+			w.WriteHeader(200)
+			w.Write([]byte(`[{"success": {"/groups/1/action/on": true}}]`))
+			return
+		}
+
+		if r.Method == "PUT" {
+			w.WriteHeader(200)
+			w.Write([]byte(`[{"error": {"type": 3, "address": "/groups/2/action", "description": "resource, /groups/2/action, not available"}}]`))
+			return
+		}
+
+		body := `{
+  "1": {
+    "name": "Living room",
+    "lights": ["1", "2"],
+    "type": "Room",
+    "class": "Living room",
+    "state": {"all_on": false, "any_on": true},
+    "action": {"on": true, "bri": 127}
+  }
+}`
+
+		if strings.HasSuffix(r.RequestURI, "/groups/1") {
+			body = `{
+  "name": "Living room",
+  "lights": ["1", "2"],
+  "type": "Room",
+  "class": "Living room",
+  "state": {"all_on": false, "any_on": true},
+  "action": {"on": true, "bri": 127}
+}`
+		}
+
+		w.WriteHeader(200)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestBridge_Groups(t *testing.T) {
+	mockServer := testServerGroups()
+	defer mockServer.Close()
+
+	bridge := &Bridge{
+		address:  strings.TrimPrefix(mockServer.URL, "https://"),
+		username: "success",
+		client:   mockServer.Client(),
+	}
+
+	expectedGroup := &Group{
+		ID:     "1",
+		Name:   "Living room",
+		Type:   "Room",
+		Class:  "Living room",
+		Lights: []string{"1", "2"},
+		Action: &State{On: true, Brightness: 127},
+		State:  &GroupState{AllOn: false, AnyOn: true},
+	}
+
+	groups, err := bridge.Groups()
+	assert.Nil(t, err)
+	assert.Nil(t, deep.Equal([]*Group{expectedGroup}, groups))
+
+	group, err := bridge.Group("1")
+	assert.Nil(t, err)
+	assert.Nil(t, deep.Equal(expectedGroup, group))
+}
+
+func TestBridge_GroupUpdateAction(t *testing.T) {
+	mockServer := testServerGroups()
+	defer mockServer.Close()
+
+	tests := []struct {
+		description   string
+		group         *Group
+		expectedError bool
+	}{
+		{
+			description:   "success",
+			group:         &Group{ID: "1"},
+			expectedError: false,
+		},
+		{
+			description:   "unknown group",
+			group:         &Group{ID: "2"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		bridge := &Bridge{
+			address:  strings.TrimPrefix(mockServer.URL, "https://"),
+			username: "success",
+			client:   mockServer.Client(),
+		}
+
+		err := bridge.GroupUpdateAction(test.group, &State{On: false})
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+	}
+}
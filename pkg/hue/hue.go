@@ -2,51 +2,57 @@
 package hue
 
 import (
-	"regexp"
+	"net/http"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/dj95/huekit/pkg/store"
 )
 
-var modelIDPattern *regexp.Regexp
-
 // Bridger Interface for interacting with the hue bridge
 type Bridger interface {
 	Light(string) (*Light, error)
 	Lights() ([]*Light, error)
 	LightUpdateState(*Light, *State) error
+	Group(string) (*Group, error)
+	Groups() ([]*Group, error)
+	GroupUpdateAction(*Group, *State) error
+	Scenes() ([]*Scene, error)
+	RecallScene(*Scene) error
+	Sensor(string) (*Sensor, error)
+	Sensors() ([]*Sensor, error)
 }
 
 // Bridge Implements handling with the hue bridge
 type Bridge struct {
 	address  string
 	username string
+	client   *http.Client
 }
 
 // NewBridge Instantiates a new bridge with the given store. If no
-// authentication is saved, it will authenticate against the bridge
+// authentication is saved, it will authenticate against the bridge.
+// The bridge is accessed via https with its certificate pinned in the
+// store on the first connection. Without address, the bridge is
+// discovered in the local network.
 func NewBridge(address string, store store.Store) (Bridger, error) {
-	// check if the username is already set in the database
-	username, err := store.Get("bridge_username")
+	// locate the bridge
+	locator := NewLocator(address, NewDiscovery(discoveryTimeout), store)
 
-	log.Debugf("%v ˆ%v", username, err)
-
-	// handle the error, if the username does not exist
-	if err != nil {
-		// authenticate
-		username, err = authenticate(address)
-	}
+	address, err := locator.Address()
 
-	// handle authentication error
+	// error handling
 	if err != nil {
 		return nil, err
 	}
 
-	// update the username in the database
-	err = store.Set("bridge_username", username)
+	// create a client, that pins the certificate of the bridge
+	client := newPinnedClient(store, locator)
 
-	// error handling
+	// load the username or authenticate
+	username, err := loadUsername(client, address, store)
+
+	// handle authentication error
 	if err != nil {
 		return nil, err
 	}
@@ -55,20 +61,29 @@ func NewBridge(address string, store store.Store) (Bridger, error) {
 	return &Bridge{
 		address:  address,
 		username: username,
+		client:   client,
 	}, nil
 }
 
-// ModelIDIsFromHue Check if the modelID matches the pattern of
-// a hue model id or not.
-func ModelIDIsFromHue(modelID string) bool {
-	// check if the pattern in this package is initialized
-	if modelIDPattern == nil {
-		// if not, initialize it with a compiled pattern in order to
-		// gain a better performance  in contrast to compiling it
-		// every time, this method is called
-		modelIDPattern = regexp.MustCompile(`^[A-Z]{3}[0-9]{3}$`)
+// loadUsername Load the username from the store. If no username is
+// saved, it will authenticate against the bridge and save the username
+func loadUsername(client *http.Client, address string, store store.Store) (string, error) {
+	// check if the username is already set in the database
+	username, err := store.Get("bridge_username")
+
+	log.Debugf("%v ˆ%v", username, err)
+
+	// handle the error, if the username does not exist
+	if err != nil {
+		// authenticate
+		username, err = authenticate(client, address)
+	}
+
+	// handle authentication error
+	if err != nil {
+		return "", err
 	}
 
-	// return if the pattern matches or not
-	return modelIDPattern.MatchString(modelID)
+	// update the username in the database
+	return username, store.Set("bridge_username", username)
 }
@@ -0,0 +1,50 @@
+package huetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"time"
+)
+
+// Certificate Generate a self-signed certificate for serving the
+// simulated bridge via https, like a real bridge does
+func Certificate() (tls.Certificate, error) {
+	// generate the private key
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// error handling
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	// real bridges use their bridge id as serial number and common name
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Organization: []string{"Philips Hue"},
+			CommonName:   "001788fffe000000",
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().AddDate(10, 0, 0),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	// sign the certificate with its own key
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	// error handling
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package huetest
+
+import (
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// DemoInventory Return a small household with genuine hue and third
+// party devices of all supported types
+func DemoInventory() *Inventory {
+	return &Inventory{
+		Lights: map[string]*hue.Light{
+			"1": {
+				Type:             "Extended color light",
+				Name:             "Living room",
+				ModelID:          "LCT015",
+				ManufacturerName: "Signify Netherlands B.V.",
+				SoftwareVersion:  "1.50.2_r30933",
+				State:            &hue.State{On: true, Brightness: 254, ColorTemperature: 366, XY: []float64{0.4573, 0.41}, ColorMode: "ct", Reachable: true},
+			},
+			"2": {
+				Type:             "Dimmable light",
+				Name:             "Hallway",
+				ModelID:          "TRADFRI bulb E27 W opal 1000lm",
+				ManufacturerName: "IKEA of Sweden",
+				SoftwareVersion:  "1.2.214",
+				State:            &hue.State{On: false, Brightness: 127, Reachable: true},
+			},
+			"3": {
+				Type:             "Color temperature light",
+				Name:             "Kitchen",
+				ModelID:          "TRADFRI bulb GU10 WS 400lm",
+				ManufacturerName: "IKEA of Sweden",
+				SoftwareVersion:  "1.2.217",
+				State:            &hue.State{On: true, Brightness: 200, ColorTemperature: 250, ColorMode: "ct", Reachable: true},
+			},
+			"4": {
+				Type:             "Extended color light",
+				Name:             "TV Stripe",
+				ModelID:          "GL-C-008",
+				ManufacturerName: "GLEDOPTO",
+				SoftwareVersion:  "1.0.2",
+				State:            &hue.State{On: false, Brightness: 180, Hue: hue.Int(8418), Saturation: hue.Int(140), XY: []float64{0.4448, 0.4066}, ColorTemperature: 343, ColorMode: "xy", Reachable: true},
+			},
+			"5": {
+				Type:             "On/Off plug-in unit",
+				Name:             "Fan",
+				ModelID:          "Plug 01",
+				ManufacturerName: "OSRAM",
+				SoftwareVersion:  "V1.04.12",
+				State:            &hue.State{On: false, Reachable: true},
+			},
+			"6": {
+				Type:             "Color light",
+				Name:             "Bloom",
+				ModelID:          "LLC011",
+				ManufacturerName: "Signify Netherlands B.V.",
+				SoftwareVersion:  "67.91.1",
+				State:            &hue.State{On: true, Brightness: 120, Hue: hue.Int(46920), Saturation: hue.Int(254), XY: []float64{0.138, 0.08}, ColorMode: "xy", Reachable: true},
+				Capabilities: &hue.Capabilities{
+					Control: &hue.Control{ColorGamutType: "A"},
+				},
+			},
+			"7": {
+				Type:             "On/Off light",
+				Name:             "Garden",
+				ModelID:          "TS0001",
+				ManufacturerName: "_TZ3000_tqlv4ug4",
+				SoftwareVersion:  "1.0.0",
+				State:            &hue.State{On: false, Reachable: true},
+			},
+		},
+		Groups: map[string]*hue.Group{
+			"1": {
+				Name:   "Downstairs",
+				Type:   "Room",
+				Class:  "Living room",
+				Lights: []string{"1", "2", "3"},
+				Action: &hue.State{On: true, Brightness: 200},
+			},
+		},
+		Scenes: map[string]*hue.Scene{
+			"dE1yU5bW9pQ4a2x": {
+				Name:   "Relax",
+				Type:   "GroupScene",
+				Group:  "1",
+				Lights: []string{"1", "2", "3"},
+			},
+		},
+		Sensors: map[string]*hue.Sensor{
+			"10": {
+				Type:             hue.SensorTypeZHATemperature,
+				Name:             "Bathroom",
+				ModelID:          "lumi.weather",
+				ManufacturerName: "LUMI",
+				SoftwareVersion:  "20161129",
+				State:            &hue.SensorState{Temperature: 2134, LastUpdated: "2020-06-01T20:10:12"},
+				Config:           &hue.SensorConfig{On: true, Reachable: true, Battery: 87},
+			},
+			"11": {
+				Type:             hue.SensorTypeZHASwitch,
+				Name:             "Remote",
+				ModelID:          "TRADFRI remote control",
+				ManufacturerName: "IKEA of Sweden",
+				SoftwareVersion:  "2.3.014",
+				State:            &hue.SensorState{ButtonEvent: 1002, LastUpdated: "2020-06-01T20:11:03"},
+				Config:           &hue.SensorConfig{On: true, Reachable: true, Battery: 74},
+			},
+		},
+	}
+}
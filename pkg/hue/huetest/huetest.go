@@ -0,0 +1,542 @@
+// Package huetest Simulate a hue bridge with an in-memory v1 api for
+// tests and local development
+package huetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dj95/huekit/pkg/hue"
+)
+
+// LinkButtonDuration Duration, for which the link button stays pressed,
+// like on a real bridge
+const LinkButtonDuration = 30 * time.Second
+
+// Errors types of the hue api, that are returned by the simulator
+const (
+	ErrorUnauthorizedUser     = 1
+	ErrorInvalidJSON          = 2
+	ErrorResourceNotAvailable = 3
+	ErrorMethodNotAvailable   = 4
+	ErrorLinkButtonNotPressed = 101
+)
+
+// Inventory Devices of the simulated bridge. It has the same format as
+// the full state of a bridge, that is returned by /api/<username>.
+type Inventory struct {
+	Lights  map[string]*hue.Light  `json:"lights"`
+	Groups  map[string]*hue.Group  `json:"groups"`
+	Scenes  map[string]*hue.Scene  `json:"scenes"`
+	Sensors map[string]*hue.Sensor `json:"sensors"`
+}
+
+// Bridge Simulated hue bridge, that implements the v1 api as
+// http.Handler
+type Bridge struct {
+	mutex      sync.Mutex
+	linkButton time.Time
+	users      map[string]string
+	inventory  *Inventory
+}
+
+type result struct {
+	Success interface{} `json:"success,omitempty"`
+	Error   *apiError   `json:"error,omitempty"`
+}
+
+type apiError struct {
+	Type        int    `json:"type"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+}
+
+// New Create a new simulated bridge with the given devices
+func New(inventory *Inventory) *Bridge {
+	// use an empty inventory, if none is given
+	if inventory == nil {
+		inventory = &Inventory{}
+	}
+
+	// initialize the missing device lists
+	if inventory.Lights == nil {
+		inventory.Lights = map[string]*hue.Light{}
+	}
+
+	if inventory.Groups == nil {
+		inventory.Groups = map[string]*hue.Group{}
+	}
+
+	if inventory.Scenes == nil {
+		inventory.Scenes = map[string]*hue.Scene{}
+	}
+
+	if inventory.Sensors == nil {
+		inventory.Sensors = map[string]*hue.Sensor{}
+	}
+
+	// set the ids of all devices
+	for id, light := range inventory.Lights {
+		light.ID = id
+	}
+
+	for id, group := range inventory.Groups {
+		group.ID = id
+	}
+
+	for id, scene := range inventory.Scenes {
+		scene.ID = id
+	}
+
+	for id, sensor := range inventory.Sensors {
+		sensor.ID = id
+	}
+
+	return &Bridge{
+		users:     map[string]string{},
+		inventory: inventory,
+	}
+}
+
+// NewServer Start a https server with a simulated bridge. The returned
+// server must be closed by the caller.
+func NewServer(inventory *Inventory) (*httptest.Server, *Bridge) {
+	bridge := New(inventory)
+
+	return httptest.NewTLSServer(bridge), bridge
+}
+
+// PressLinkButton Press the link button, such that new users can
+// authenticate within the LinkButtonDuration
+func (b *Bridge) PressLinkButton() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.linkButton = time.Now()
+}
+
+// AddUser Add an authenticated user to the bridge
+func (b *Bridge) AddUser(username string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.users[username] = "huetest"
+}
+
+// Light Return a copy of the light with the given id
+func (b *Bridge) Light(id string) *hue.Light {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	light, ok := b.inventory.Lights[id]
+	if !ok {
+		return nil
+	}
+
+	// copy the light, such that the caller cannot modify the
+	// simulated state
+	result := *light
+
+	if light.State != nil {
+		state := *light.State
+		result.State = &state
+	}
+
+	return &result
+}
+
+// AddLight Add a light with the given id, e.g. in order to simulate
+// pairing a new bulb. An existing light with this id is replaced.
+func (b *Bridge) AddLight(id string, light *hue.Light) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	light.ID = id
+	b.inventory.Lights[id] = light
+
+	b.updateGroupStates()
+}
+
+// DeleteLight Remove the light with the given id from the bridge
+func (b *Bridge) DeleteLight(id string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.inventory.Lights, id)
+
+	b.updateGroupStates()
+}
+
+// SetLightState Replace the state of a light, e.g. in order to simulate
+// a change in the hue app
+func (b *Bridge) SetLightState(id string, state *hue.State) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if light, ok := b.inventory.Lights[id]; ok {
+		light.State = state
+	}
+
+	b.updateGroupStates()
+}
+
+// SetSensorState Replace the state of a sensor, e.g. in order to
+// simulate a button press or motion
+func (b *Bridge) SetSensorState(id string, state *hue.SensorState) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if sensor, ok := b.inventory.Sensors[id]; ok {
+		sensor.State = state
+	}
+}
+
+// ServeHTTP Handle the requests against the v1 api
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// split the path into its segments, e.g.
+	// /api/<username>/lights/1/state
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// the link button can be pressed remotely, as the simulated
+	// bridge has no physical one
+	if r.Method == "POST" && path[0] == "linkbutton" && len(path) == 1 {
+		b.linkButton = time.Now()
+
+		writeJSON(w, []result{{Success: map[string]bool{"/config/linkbutton": true}}})
+
+		return
+	}
+
+	// every request must be sent to the api
+	if path[0] != "api" {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	// the authentication request is the only one without a username
+	if len(path) == 1 {
+		b.handleAuthentication(w, r)
+
+		return
+	}
+
+	// verify the username
+	if _, ok := b.users[path[1]]; !ok {
+		writeError(w, ErrorUnauthorizedUser, "/", "unauthorized user")
+
+		return
+	}
+
+	address := "/" + strings.Join(path[2:], "/")
+
+	switch {
+	case r.Method == "GET" && len(path) == 2:
+		writeJSON(w, b.inventory)
+	case r.Method == "GET" && len(path) == 3:
+		b.handleList(w, path[2], address)
+	case r.Method == "GET" && len(path) == 4:
+		b.handleGet(w, path[2], path[3], address)
+	case r.Method == "PUT" && len(path) == 5 && path[2] == "lights" && path[4] == "state":
+		b.handleLightState(w, r, path[3], address)
+	case r.Method == "PUT" && len(path) == 5 && path[2] == "groups" && path[4] == "action":
+		b.handleGroupAction(w, r, path[3], address)
+	default:
+		writeError(w, ErrorMethodNotAvailable, address, fmt.Sprintf("method, %s, not available for resource, %s", r.Method, address))
+	}
+}
+
+func (b *Bridge) handleAuthentication(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, ErrorMethodNotAvailable, "/", fmt.Sprintf("method, %s, not available for resource, /", r.Method))
+
+		return
+	}
+
+	var body struct {
+		DeviceType string `json:"devicetype"`
+	}
+
+	// decode the request body
+	if err := decodeBody(r, &body); err != nil || body.DeviceType == "" {
+		writeError(w, ErrorInvalidJSON, "", "body contains invalid json")
+
+		return
+	}
+
+	// new users can only be created within 30 seconds after the
+	// link button was pressed
+	if time.Since(b.linkButton) > LinkButtonDuration {
+		writeError(w, ErrorLinkButtonNotPressed, "", "link button not pressed")
+
+		return
+	}
+
+	// generate a new username
+	seed := make([]byte, 20)
+	if _, err := rand.Read(seed); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	username := hex.EncodeToString(seed)
+	b.users[username] = body.DeviceType
+
+	writeJSON(w, []result{{Success: map[string]string{"username": username}}})
+}
+
+func (b *Bridge) handleList(w http.ResponseWriter, resource, address string) {
+	switch resource {
+	case "lights":
+		writeJSON(w, b.inventory.Lights)
+	case "groups":
+		writeJSON(w, b.inventory.Groups)
+	case "scenes":
+		writeJSON(w, b.inventory.Scenes)
+	case "sensors":
+		writeJSON(w, b.inventory.Sensors)
+	default:
+		writeResourceNotAvailable(w, address)
+	}
+}
+
+func (b *Bridge) handleGet(w http.ResponseWriter, resource, id, address string) {
+	var device interface{}
+	var ok bool
+
+	// look up the device
+	switch resource {
+	case "lights":
+		device, ok = b.inventory.Lights[id]
+	case "groups":
+		device, ok = b.inventory.Groups[id]
+	case "scenes":
+		device, ok = b.inventory.Scenes[id]
+	case "sensors":
+		device, ok = b.inventory.Sensors[id]
+	}
+
+	if !ok {
+		writeResourceNotAvailable(w, address)
+
+		return
+	}
+
+	writeJSON(w, device)
+}
+
+func (b *Bridge) handleLightState(w http.ResponseWriter, r *http.Request, id, address string) {
+	light, ok := b.inventory.Lights[id]
+	if !ok {
+		writeResourceNotAvailable(w, address)
+
+		return
+	}
+
+	// decode the changes
+	var changes map[string]json.RawMessage
+	if err := decodeBody(r, &changes); err != nil {
+		writeError(w, ErrorInvalidJSON, address, "body contains invalid json")
+
+		return
+	}
+
+	// apply the changes to the light
+	if light.State == nil {
+		light.State = &hue.State{}
+	}
+
+	results := applyState(light.State, changes, address)
+
+	b.updateGroupStates()
+
+	writeJSON(w, results)
+}
+
+func (b *Bridge) handleGroupAction(w http.ResponseWriter, r *http.Request, id, address string) {
+	group, ok := b.inventory.Groups[id]
+
+	// the group 0 always contains all lights
+	if id == "0" {
+		group, ok = b.allLightsGroup(), true
+	}
+
+	if !ok {
+		writeResourceNotAvailable(w, address)
+
+		return
+	}
+
+	// decode the changes
+	var changes map[string]json.RawMessage
+	if err := decodeBody(r, &changes); err != nil {
+		writeError(w, ErrorInvalidJSON, address, "body contains invalid json")
+
+		return
+	}
+
+	lights := group.Lights
+
+	// a scene turns on all of its lights
+	if rawScene, ok := changes["scene"]; ok {
+		var sceneID string
+		_ = json.Unmarshal(rawScene, &sceneID)
+
+		scene, ok := b.inventory.Scenes[sceneID]
+		if !ok {
+			writeError(w, ErrorResourceNotAvailable, address+"/scene", fmt.Sprintf("resource, /scenes/%s, not available", sceneID))
+
+			return
+		}
+
+		delete(changes, "scene")
+		changes["on"] = json.RawMessage("true")
+		lights = scene.Lights
+	}
+
+	// apply the changes to the group and all of its lights
+	if group.Action == nil {
+		group.Action = &hue.State{}
+	}
+
+	results := applyState(group.Action, changes, address)
+
+	for _, lightID := range lights {
+		if light, ok := b.inventory.Lights[lightID]; ok && light.State != nil {
+			applyState(light.State, changes, address)
+		}
+	}
+
+	b.updateGroupStates()
+
+	writeJSON(w, results)
+}
+
+// allLightsGroup Return the special group 0, that contains all lights
+func (b *Bridge) allLightsGroup() *hue.Group {
+	group := &hue.Group{ID: "0", Name: "Group 0", Type: "LightGroup", Action: &hue.State{}}
+
+	for id := range b.inventory.Lights {
+		group.Lights = append(group.Lights, id)
+	}
+
+	return group
+}
+
+// updateGroupStates Recalculate the state of all groups based on the
+// states of their lights. The mutex must be held by the caller.
+func (b *Bridge) updateGroupStates() {
+	for _, group := range b.inventory.Groups {
+		state := &hue.GroupState{AllOn: len(group.Lights) > 0}
+
+		for _, id := range group.Lights {
+			light, ok := b.inventory.Lights[id]
+			if !ok || light.State == nil {
+				continue
+			}
+
+			state.AnyOn = state.AnyOn || light.State.On
+			state.AllOn = state.AllOn && light.State.On
+		}
+
+		group.State = state
+	}
+}
+
+// applyState Apply the changes to the state and return the results, that
+// the bridge responds with
+func applyState(state *hue.State, changes map[string]json.RawMessage, address string) []result {
+	var results []result
+
+	for key, raw := range changes {
+		var target interface{}
+
+		// select the field, that should be changed
+		switch key {
+		case "on":
+			target = &state.On
+		case "bri":
+			target = &state.Brightness
+		case "hue":
+			target, state.ColorMode = &state.Hue, "hs"
+		case "sat":
+			target, state.ColorMode = &state.Saturation, "hs"
+		case "xy":
+			target, state.ColorMode = &state.XY, "xy"
+		case "ct":
+			target, state.ColorMode = &state.ColorTemperature, "ct"
+		case "alert":
+			target = &state.Alert
+		case "effect":
+			target = &state.Effect
+		case "transitiontime":
+			// transitions are not simulated
+			continue
+		default:
+			results = append(results, result{Error: &apiError{
+				Type:        6,
+				Address:     address + "/" + key,
+				Description: fmt.Sprintf("parameter, %s, not available", key),
+			}})
+
+			continue
+		}
+
+		// set the new value
+		if err := json.Unmarshal(raw, target); err != nil {
+			results = append(results, result{Error: &apiError{
+				Type:        7,
+				Address:     address + "/" + key,
+				Description: fmt.Sprintf("invalid value, %s, for parameter, %s", string(raw), key),
+			}})
+
+			continue
+		}
+
+		results = append(results, result{Success: map[string]json.RawMessage{
+			address + "/" + key: raw,
+		}})
+	}
+
+	return results
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, typ int, address, description string) {
+	writeJSON(w, []result{{Error: &apiError{
+		Type:        typ,
+		Address:     address,
+		Description: description,
+	}}})
+}
+
+func writeResourceNotAvailable(w http.ResponseWriter, address string) {
+	writeError(w, ErrorResourceNotAvailable, address, fmt.Sprintf("resource, %s, not available", address))
+}
@@ -0,0 +1,132 @@
+package huetest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/hue"
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestBridge_Authentication(t *testing.T) {
+	server, simulator := NewServer(DemoInventory())
+	defer server.Close()
+
+	address := strings.TrimPrefix(server.URL, "https://")
+	memory := store.NewMemory(nil)
+
+	// authenticate with the pressed link button
+	simulator.PressLinkButton()
+
+	bridge, err := hue.NewBridge(address, memory)
+	assert.Nil(t, err)
+	for _, key := range []string{"bridge_username", "bridge_certificate"} {
+		value, err := memory.Get(key)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, value)
+	}
+
+	lights, err := bridge.Lights()
+	assert.Nil(t, err)
+	assert.Len(t, lights, 7)
+
+	// use an unknown username
+	unknown, err := hue.NewBridge(address, store.NewMemory(map[string]string{"bridge_username": "unknown"}))
+	assert.Nil(t, err)
+
+	_, err = unknown.Lights()
+	assert.EqualError(t, err, "unauthorized user")
+}
+
+func TestBridge_LightUpdateState(t *testing.T) {
+	server, simulator := NewServer(DemoInventory())
+	defer server.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(server.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description   string
+		light         *hue.Light
+		state         *hue.State
+		expectedError bool
+		expectedState *hue.State
+	}{
+		{
+			description:   "turn on and dim",
+			light:         &hue.Light{ID: "2"},
+			state:         &hue.State{On: true, Brightness: 50},
+			expectedError: false,
+			expectedState: &hue.State{On: true, Brightness: 50, Reachable: true},
+		},
+		{
+			description:   "change the color temperature",
+			light:         &hue.Light{ID: "4"},
+			state:         &hue.State{On: true, ColorTemperature: 200},
+			expectedError: false,
+			expectedState: &hue.State{On: true, Brightness: 180, Hue: hue.Int(8418), Saturation: hue.Int(140), XY: []float64{0.4448, 0.4066}, ColorTemperature: 200, ColorMode: "ct", Reachable: true},
+		},
+		{
+			description:   "unknown light",
+			light:         &hue.Light{ID: "42"},
+			state:         &hue.State{On: true},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := bridge.LightUpdateState(test.light, test.state)
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+
+		if test.expectedState == nil {
+			continue
+		}
+
+		light, err := bridge.Light(test.light.ID)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedState, light.State, test.description)
+	}
+}
+
+func TestBridge_GroupUpdateAction(t *testing.T) {
+	server, simulator := NewServer(DemoInventory())
+	defer server.Close()
+
+	simulator.AddUser("success")
+
+	bridge, err := hue.NewBridge(
+		strings.TrimPrefix(server.URL, "https://"),
+		store.NewMemory(map[string]string{"bridge_username": "success"}),
+	)
+	assert.Nil(t, err)
+
+	// turn off all lights of the room
+	err = bridge.GroupUpdateAction(&hue.Group{ID: "1"}, &hue.State{On: false})
+	assert.Nil(t, err)
+
+	group, err := bridge.Group("1")
+	assert.Nil(t, err)
+	assert.Equal(t, &hue.GroupState{AllOn: false, AnyOn: false}, group.State)
+
+	for _, id := range []string{"1", "2", "3"} {
+		assert.False(t, simulator.Light(id).State.On)
+	}
+
+	// recall a scene
+	err = bridge.RecallScene(&hue.Scene{ID: "dE1yU5bW9pQ4a2x", Group: "1"})
+	assert.Nil(t, err)
+
+	group, err = bridge.Group("1")
+	assert.Nil(t, err)
+	assert.Equal(t, &hue.GroupState{AllOn: true, AnyOn: true}, group.State)
+}
@@ -1,11 +1,10 @@
 package hue
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"math"
+	"sort"
+	"strconv"
+	"time"
 )
 
 // Light Represents a light/plug at the hue bridge
@@ -16,112 +15,126 @@ type Light struct {
 	ModelID          string            `json:"modelid"`
 	ManufacturerName string            `json:"manufacturername"`
 	SoftwareVersion  string            `json:"swversion"`
+	ProductID        string            `json:"productid"`
+	UniqueID         string            `json:"uniqueid"`
 	State            *State            `json:"state"`
 	PointSymbol      map[string]string `json:"pointsymbol"`
+	Capabilities     *Capabilities     `json:"capabilities,omitempty"`
+}
+
+// Capabilities Represents the features of a light
+type Capabilities struct {
+	Control *Control `json:"control,omitempty"`
+}
+
+// Control Represents the ranges of the colors, that a light can show
+type Control struct {
+	// ColorGamutType Type of the color gamut, e.g. A, B or C
+	ColorGamutType string `json:"colorgamuttype,omitempty"`
+
+	// ColorGamut Red, green and blue corner of the color gamut in the
+	// CIE xy color space
+	ColorGamut [][]float64 `json:"colorgamut,omitempty"`
+}
+
+// Gamut Return the type and the corners of the color gamut of the light.
+// Both are empty, if the light reports no color gamut.
+func (l *Light) Gamut() (string, [][]float64) {
+	if l.Capabilities == nil || l.Capabilities.Control == nil {
+		return "", nil
+	}
+
+	return l.Capabilities.Control.ColorGamutType, l.Capabilities.Control.ColorGamut
 }
 
 // State Represents the state of a light
 type State struct {
 	On               bool      `json:"on"`
 	Brightness       int       `json:"bri,omitempty"`
-	Hue              int       `json:"hue,omitempty"`
-	Saturation       int       `json:"sat,omitempty"`
+	Hue              *int      `json:"hue,omitempty"`
+	Saturation       *int      `json:"sat,omitempty"`
 	XY               []float64 `json:"xy,omitempty"`
 	ColorTemperature int       `json:"ct,omitempty"`
 	Alert            string    `json:"alert,omitempty"`
 	Effect           string    `json:"effect,omitempty"`
 	ColorMode        string    `json:"colormode,omitempty"`
 	Reachable        bool      `json:"reachable,omitempty"`
-}
 
-// LightName Represents the lights name in the /lights api call
-type LightName struct {
-	Name string `json:"name"`
+	// TransitionTime Duration of the fade to the state in multiples
+	// of 100ms. Nil uses the default of the bridge of 400ms.
+	TransitionTime *int `json:"transitiontime,omitempty"`
 }
 
-// Lights Query and return all lights
-func (b *Bridge) Lights() ([]*Light, error) {
-	// perform the api request to fetch all lights
-	res, err := http.Get(
-		"http://" + b.address + "/api/" + b.username + "/lights",
-	)
-
-	// handle http errors
-	if err != nil {
-		return nil, err
-	}
+// maxTransitionTime Longest transition time, that the bridge accepts
+const maxTransitionTime = 65535
 
-	// close the response body on return in order to avoid memory
-	// leaks
-	defer res.Body.Close()
+// TransitionTime Convert the duration into the transition time of a
+// state, that is rounded to multiples of 100ms
+func TransitionTime(duration time.Duration) *int {
+	transitionTime := int(math.Round(float64(duration) / float64(100*time.Millisecond)))
+	transitionTime = min(maxTransitionTime, max(0, transitionTime))
 
-	// allocate the structure for the response body in memory
-	var lightIDs map[string]*LightName
-
-	// read the body
-	bodyBytes, err := io.ReadAll(res.Body)
+	return &transitionTime
+}
 
-	// handle read errors
-	if err != nil {
-		return nil, err
-	}
+// Int Return a pointer to the value of an optional field of a state, such
+// that zero values, e.g. the red hue 0, are sent to the bridge
+func Int(value int) *int {
+	return &value
+}
 
-	// unmarshal the json body
-	err = json.Unmarshal(bodyBytes, &lightIDs)
+// Lights Query and return all lights
+func (b *Bridge) Lights() ([]*Light, error) {
+	// allocate the structure for the response body in memory
+	var lightMap map[string]*Light
 
-	// handle json decoding errors
-	if err != nil {
+	// perform the api request to fetch all lights. The /lights call
+	// already contains the full information of every light, so there
+	// is no need to query each light on its own.
+	if err := b.get("/lights", &lightMap); err != nil {
 		return nil, err
 	}
 
 	var lights []*Light
 
-	for id := range lightIDs {
-		light, err := b.Light(id)
-
-		if err != nil {
-			return nil, err
-		}
+	for id, light := range lightMap {
+		// add the ID to the light
+		light.ID = id
 
 		lights = append(lights, light)
 	}
 
+	// sort the lights by their id in order to always return them
+	// in the same order
+	sort.Slice(lights, func(i, j int) bool {
+		return lessID(lights[i].ID, lights[j].ID)
+	})
+
 	// return the result
 	return lights, nil
 }
 
-// Light Query and return a light by its id
-func (b *Bridge) Light(id string) (*Light, error) {
-	// perform the api request to fetch all lights
-	res, err := http.Get(
-		"http://" + b.address + "/api/" + b.username + "/lights/" + id,
-	)
+// lessID Compare two ids of the bridge. Numeric ids are compared by
+// their value, all others lexically.
+func lessID(a, b string) bool {
+	numA, errA := strconv.Atoi(a)
+	numB, errB := strconv.Atoi(b)
 
-	// handle http errors
-	if err != nil {
-		return nil, err
+	// compare numerically, if both ids are numbers
+	if errA == nil && errB == nil {
+		return numA < numB
 	}
 
-	// close the response body on return in order to avoid memory
-	// leaks
-	defer res.Body.Close()
+	return a < b
+}
 
+// Light Query and return a light by its id
+func (b *Bridge) Light(id string) (*Light, error) {
 	// allocate the structure for the response body in memory
 	var light Light
 
-	// read the body
-	bodyBytes, err := io.ReadAll(res.Body)
-
-	// handle read errors
-	if err != nil {
-		return nil, err
-	}
-
-	// unmarshal the json body
-	err = json.Unmarshal(bodyBytes, &light)
-
-	// handle json decoding errors
-	if err != nil {
+	// perform the api request to fetch the light
+	if err := b.get("/lights/"+id, &light); err != nil {
 		return nil, err
 	}
 
@@ -131,59 +144,7 @@ func (b *Bridge) Light(id string) (*Light, error) {
 	return &light, nil
 }
 
-type toggleResponse struct {
-	Error   *errorResp   `json:"error"`
-	Success *successResp `json:"success"`
-}
-
 // LightUpdateState Update the state of a light
 func (b *Bridge) LightUpdateState(light *Light, state *State) error {
-	// create the request body
-	body, err := json.Marshal(state)
-
-	if err != nil {
-		return err
-	}
-
-	// perform the api request to fetch all lights
-	req, err := http.NewRequest(
-		"PUT",
-		"http://"+b.address+"/api/"+b.username+"/lights/"+light.ID+"/state",
-		bytes.NewBuffer(body),
-	)
-
-	// handle http errors
-	if err != nil {
-		return err
-	}
-
-	res, err := http.DefaultClient.Do(req)
-
-	if err != nil {
-		return err
-	}
-
-	defer res.Body.Close()
-
-	resByte, err := io.ReadAll(res.Body)
-
-	if err != nil {
-		return err
-	}
-
-	var toggleResp []toggleResponse
-
-	if err := json.Unmarshal(resByte, &toggleResp); err != nil {
-		return err
-	}
-
-	err = nil
-
-	for _, res := range toggleResp {
-		if res.Error != nil {
-			err = fmt.Errorf(res.Error.Description)
-		}
-	}
-
-	return err
+	return countUpdateError(b.put("/lights/"+light.ID+"/state", state))
 }
@@ -5,13 +5,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	"github.com/stretchr/testify/assert"
 )
 
 func testServerLights() *httptest.Server {
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.RequestURI, "/lights") {
 			lightsHandler(w, r)
 
@@ -30,9 +31,7 @@ func lightsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	body := `{
-  "1": {
-    "name": "TV Left"
-  }
+  "1": ` + testLightBody + `
 }`
 
 	w.WriteHeader(200)
@@ -46,7 +45,11 @@ func lightHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body := `{
+	w.WriteHeader(200)
+	w.Write([]byte(testLightBody))
+}
+
+const testLightBody = `{
   "state": {
     "on": true,
     "bri": 202,
@@ -66,6 +69,20 @@ func lightHandler(w http.ResponseWriter, r *http.Request) {
   "name": "TV Left",
   "modelid": "LCT001",
   "swversion": "65003148",
+  "capabilities": {
+    "control": {
+      "colorgamuttype": "B",
+      "colorgamut": [
+        [0.675, 0.322],
+        [0.409, 0.518],
+        [0.167, 0.04]
+      ],
+      "ct": {
+        "min": 153,
+        "max": 500
+      }
+    }
+  },
   "pointsymbol": {
     "1": "none",
     "2": "none",
@@ -78,10 +95,6 @@ func lightHandler(w http.ResponseWriter, r *http.Request) {
   }
 }`
 
-	w.WriteHeader(200)
-	w.Write([]byte(body))
-}
-
 func TestBridge_Lights(t *testing.T) {
 	mockServer := testServerLights()
 
@@ -94,7 +107,7 @@ func TestBridge_Lights(t *testing.T) {
 	}{
 		{
 			description:   "success",
-			address:       strings.TrimLeft(mockServer.URL, "htp:/"),
+			address:       strings.TrimPrefix(mockServer.URL, "https://"),
 			username:      "success",
 			expectedError: false,
 			expectedResult: []*Light{
@@ -107,8 +120,8 @@ func TestBridge_Lights(t *testing.T) {
 					State: &State{
 						On:               true,
 						Brightness:       202,
-						Hue:              13122,
-						Saturation:       211,
+						Hue:              Int(13122),
+						Saturation:       Int(211),
 						XY:               []float64{0.5119, 0.4147},
 						ColorTemperature: 467,
 						Alert:            "none",
@@ -126,6 +139,10 @@ func TestBridge_Lights(t *testing.T) {
 						"7": "none",
 						"8": "none",
 					},
+					Capabilities: &Capabilities{Control: &Control{
+						ColorGamutType: "B",
+						ColorGamut:     [][]float64{{0.675, 0.322}, {0.409, 0.518}, {0.167, 0.04}},
+					}},
 				},
 			},
 		},
@@ -135,6 +152,7 @@ func TestBridge_Lights(t *testing.T) {
 		bridge := &Bridge{
 			address:  test.address,
 			username: test.username,
+			client:   mockServer.Client(),
 		}
 
 		result, err := bridge.Lights()
@@ -143,3 +161,36 @@ func TestBridge_Lights(t *testing.T) {
 		assert.Nilf(t, deep.Equal(test.expectedResult, result), test.description)
 	}
 }
+
+func TestTransitionTime(t *testing.T) {
+	tests := []struct {
+		description    string
+		duration       time.Duration
+		expectedResult int
+	}{
+		{
+			description:    "instant",
+			duration:       0,
+			expectedResult: 0,
+		},
+		{
+			description:    "rounded to 100ms",
+			duration:       1260 * time.Millisecond,
+			expectedResult: 13,
+		},
+		{
+			description:    "negative duration",
+			duration:       -time.Second,
+			expectedResult: 0,
+		},
+		{
+			description:    "longer than the bridge supports",
+			duration:       3 * time.Hour,
+			expectedResult: 65535,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.expectedResult, *TransitionTime(test.duration), test.description)
+	}
+}
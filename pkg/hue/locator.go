@@ -0,0 +1,197 @@
+package hue
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+const (
+	// bridgeIDKey Key of the id of the located bridge in the store
+	bridgeIDKey = "bridge_id"
+
+	// discoveryTimeout Duration, for which answers of bridges are
+	// awaited during the discovery
+	discoveryTimeout = 3 * time.Second
+
+	// dialTimeout Timeout for connecting to the bridge, after which
+	// the bridge is discovered again
+	dialTimeout = 5 * time.Second
+)
+
+// Locator Keeps track of the address of the bridge. Without a configured
+// address, the bridge is discovered in the local network and found again
+// by its id, when its address changed.
+type Locator struct {
+	discovery *Discovery
+	store     store.Store
+
+	mutex   sync.Mutex
+	address string
+}
+
+// NewLocator Create a new locator. A configured address is used as is,
+// otherwise the bridge is searched with the given discovery.
+func NewLocator(address string, discovery *Discovery, store store.Store) *Locator {
+	// a configured address does not need to be discovered
+	if address != "" {
+		discovery = nil
+	}
+
+	return &Locator{
+		discovery: discovery,
+		store:     store,
+		address:   address,
+	}
+}
+
+// Address Return the address of the bridge and discover it, if it is
+// not known yet
+func (l *Locator) Address() (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// return the known address
+	if l.address != "" {
+		return l.address, nil
+	}
+
+	return l.locate()
+}
+
+// Relocate Discover the bridge again, as it cannot be reached on the
+// given address anymore
+func (l *Locator) Relocate(failed string) (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// configured addresses cannot be relocated
+	if l.discovery == nil {
+		return "", fmt.Errorf("cannot relocate the configured bridge address %s", l.address)
+	}
+
+	// another connection already relocated the bridge
+	if l.address != failed {
+		return l.address, nil
+	}
+
+	return l.locate()
+}
+
+// DialContext Connect to the bridge on its current address. If the
+// connection fails, the bridge is discovered again and the connection
+// is retried on its new address.
+func (l *Locator) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	// the port of the request is used for addresses without port
+	_, port, err := net.SplitHostPort(addr)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	address := l.address
+	l.mutex.Unlock()
+
+	conn, err := dialer.DialContext(ctx, network, withPort(address, port))
+
+	// return the connection, if the bridge is reachable or cannot be
+	// relocated
+	if err == nil || l.discovery == nil {
+		return conn, err
+	}
+
+	log.Warnf("cannot connect to the bridge on %s, discovering it again: %s", address, err.Error())
+
+	relocated, relocateErr := l.Relocate(address)
+
+	// return the original error, if the bridge was not found
+	if relocateErr != nil {
+		log.Errorf("cannot discover the bridge: %s", relocateErr.Error())
+
+		return nil, err
+	}
+
+	return dialer.DialContext(ctx, network, withPort(relocated, port))
+}
+
+// locate Discover the bridges and select the bridge with the saved id.
+// Without a saved id, the only discovered bridge is selected and its id
+// is saved. The mutex must be held by the caller.
+func (l *Locator) locate() (string, error) {
+	// a configured address cannot be discovered
+	if l.discovery == nil {
+		return "", fmt.Errorf("no bridge address configured")
+	}
+
+	bridges, err := l.discovery.Discover()
+
+	// error handling
+	if err != nil {
+		return "", err
+	}
+
+	// search the known bridge by its id
+	if id, err := l.store.Get(bridgeIDKey); err == nil {
+		for _, bridge := range bridges {
+			if bridge.ID == id {
+				return l.use(bridge)
+			}
+		}
+
+		return "", fmt.Errorf("bridge %s not found", id)
+	}
+
+	switch len(bridges) {
+	case 0:
+		return "", fmt.Errorf("no bridge found")
+	case 1:
+		// save the id in order to find the bridge again, even if other
+		// bridges are added to the network
+		if err := l.store.Set(bridgeIDKey, bridges[0].ID); err != nil {
+			return "", err
+		}
+
+		return l.use(bridges[0])
+	}
+
+	var found []string
+
+	for _, bridge := range bridges {
+		found = append(found, bridge.ID+" ("+bridge.Address+")")
+	}
+
+	return "", fmt.Errorf("found multiple bridges, configure the address of one: %s", strings.Join(found, ", "))
+}
+
+// use Use the address of the bridge. The mutex must be held by the
+// caller.
+func (l *Locator) use(bridge *DiscoveredBridge) (string, error) {
+	log.WithFields(log.Fields{
+		"id":      bridge.ID,
+		"address": bridge.Address,
+	}).Info("located bridge")
+
+	l.address = bridge.Address
+
+	return l.address, nil
+}
+
+// withPort Add the port to the address, if it does not contain one
+func withPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+
+	return net.JoinHostPort(address, port)
+}
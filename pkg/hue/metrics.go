@@ -0,0 +1,201 @@
+package hue
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// requestsTotal Number of requests against the api of the bridges
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "huekit_hue_requests_total",
+		Help: "Number of requests against the api of the hue bridges by endpoint and status code.",
+	}, []string{"address", "endpoint", "status"})
+
+	// requestDuration Latency of the requests against the api of the
+	// bridges
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "huekit_hue_request_duration_seconds",
+		Help:    "Latency of the requests against the api of the hue bridges by endpoint and status code.",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"address", "endpoint", "status"})
+
+	// lightUpdateErrorsTotal Number of failed light updates
+	lightUpdateErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "huekit_hue_light_update_errors_total",
+		Help: "Number of failed light state updates by the type of the hue error.",
+	}, []string{"type"})
+
+	// contacts Time of the last successful request against every
+	// bridge
+	contacts = newContactCollector()
+)
+
+func init() {
+	prometheus.MustRegister(contacts)
+}
+
+// APIError Error, that is returned by the api of the bridge
+type APIError struct {
+	// Type Type of the error as documented by hue, e.g. 201 when
+	// the state of a light, that is turned off, is changed. The
+	// CLIP v2 api returns no type.
+	Type int
+
+	// Description Human readable description of the error
+	Description string
+}
+
+// Error Return the description of the error
+func (e *APIError) Error() string {
+	return e.Description
+}
+
+// instrumentedTransport Record the metrics of all requests against the
+// bridges
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip Perform the request and record its status and latency
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	res, err := t.next.RoundTrip(req)
+
+	status := "error"
+
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+
+		// every answer of the bridge, that is no server error,
+		// counts as contact
+		if res.StatusCode < http.StatusInternalServerError {
+			contacts.seen(req.URL.Host)
+		}
+	}
+
+	labels := prometheus.Labels{
+		"address":  req.URL.Host,
+		"endpoint": endpoint(req.URL.Path),
+		"status":   status,
+	}
+
+	requestsTotal.With(labels).Inc()
+	requestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
+
+// endpoint Replace the username and the ids in the path of a request,
+// such that all requests against the same endpoint share their metrics
+func endpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	// /api/<username>/<resource>/<id>/...
+	case segments[0] == "api":
+		if len(segments) > 1 {
+			segments[1] = "{username}"
+		}
+
+		if len(segments) > 3 {
+			segments[3] = "{id}"
+		}
+
+	// /clip/v2/resource/<type>/<id>
+	case segments[0] == "clip":
+		if len(segments) > 4 {
+			segments[4] = "{id}"
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// countUpdateError Count the error of a light update by its type and
+// return it
+func countUpdateError(err error) error {
+	if err != nil {
+		lightUpdateErrorsTotal.WithLabelValues(errorType(err)).Inc()
+	}
+
+	return err
+}
+
+// errorType Return the type of the hue error or the kind of the failure,
+// if the bridge did not answer
+func errorType(err error) string {
+	var apiError *APIError
+	var netError net.Error
+
+	switch {
+	case errors.As(err, &apiError) && apiError.Type != 0:
+		return strconv.Itoa(apiError.Type)
+	case errors.As(err, &apiError):
+		return "bridge"
+	case errors.Is(err, ErrCertificateChanged):
+		return "certificate"
+	case errors.As(err, &netError):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// contactCollector Export the time since the last successful request
+// against every bridge, which is calculated on every scrape
+type contactCollector struct {
+	description *prometheus.Desc
+
+	mutex sync.Mutex
+	last  map[string]time.Time
+}
+
+func newContactCollector() *contactCollector {
+	return &contactCollector{
+		description: prometheus.NewDesc(
+			"huekit_hue_last_contact_seconds",
+			"Time since the last successful request against the hue bridge.",
+			[]string{"address"},
+			nil,
+		),
+		last: map[string]time.Time{},
+	}
+}
+
+// seen Save the current time as last contact with the bridge
+func (c *contactCollector) seen(address string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.last[address] = time.Now()
+}
+
+// Describe Send the description of the metric
+func (c *contactCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- c.description
+}
+
+// Collect Send the time since the last contact for every bridge
+func (c *contactCollector) Collect(metrics chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for address, last := range c.last {
+		metrics <- prometheus.MustNewConstMetric(
+			c.description,
+			prometheus.GaugeValue,
+			time.Since(last).Seconds(),
+			address,
+		)
+	}
+}
@@ -0,0 +1,133 @@
+package hue
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dj95/huekit/pkg/store"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		description    string
+		path           string
+		expectedResult string
+	}{
+		{
+			description:    "authentication",
+			path:           "/api",
+			expectedResult: "/api",
+		},
+		{
+			description:    "full state",
+			path:           "/api/1234abcd",
+			expectedResult: "/api/{username}",
+		},
+		{
+			description:    "all lights",
+			path:           "/api/1234abcd/lights",
+			expectedResult: "/api/{username}/lights",
+		},
+		{
+			description:    "light state",
+			path:           "/api/1234abcd/lights/12/state",
+			expectedResult: "/api/{username}/lights/{id}/state",
+		},
+		{
+			description:    "v2 resources",
+			path:           "/clip/v2/resource/light",
+			expectedResult: "/clip/v2/resource/light",
+		},
+		{
+			description:    "v2 resource",
+			path:           "/clip/v2/resource/light/3f6f4b8e-1f4b-4e0a-9b7e-4c6b2c1f0a11",
+			expectedResult: "/clip/v2/resource/light/{id}",
+		},
+		{
+			description:    "event stream",
+			path:           "/eventstream/clip/v2",
+			expectedResult: "/eventstream/clip/v2",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.expectedResult, endpoint(test.path), test.description)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		description    string
+		err            error
+		expectedResult string
+	}{
+		{
+			description:    "v1 error",
+			err:            &APIError{Type: 201, Description: "parameter, bri, is not modifiable. Device is set to off."},
+			expectedResult: "201",
+		},
+		{
+			description:    "v2 error",
+			err:            &APIError{Description: "device (light) is \"soft off\", command (.dimming.brightness) may not have effect"},
+			expectedResult: "bridge",
+		},
+		{
+			description:    "changed certificate",
+			err:            fmt.Errorf("Put \"https://192.168.1.2/api\": %w", ErrCertificateChanged),
+			expectedResult: "certificate",
+		},
+		{
+			description:    "unreachable bridge",
+			err:            &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expectedResult: "network",
+		},
+		{
+			description:    "unknown error",
+			err:            errors.New("light 7 not found"),
+			expectedResult: "other",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.expectedResult, errorType(test.err), test.description)
+	}
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"error":{"type":201,"address":"/lights/1/state/bri","description":"parameter, bri, is not modifiable. Device is set to off."}}]`))
+	}))
+	defer mockServer.Close()
+
+	address := strings.TrimPrefix(mockServer.URL, "https://")
+
+	bridge := &Bridge{
+		address:  address,
+		username: "success",
+		client:   newPinnedClient(store.NewMemory(nil), nil),
+	}
+
+	before := testutil.ToFloat64(lightUpdateErrorsTotal.WithLabelValues("201"))
+
+	err := bridge.LightUpdateState(&Light{ID: "1"}, &State{Brightness: 127})
+
+	assert.Equal(t, &APIError{Type: 201, Description: "parameter, bri, is not modifiable. Device is set to off."}, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(lightUpdateErrorsTotal.WithLabelValues("201")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requestsTotal.WithLabelValues(address, "/api/{username}/lights/{id}/state", "200")))
+
+	// the bridge answered, so it was contacted just now
+	contacts.mutex.Lock()
+	assert.WithinDuration(t, time.Now(), contacts.last[address], time.Second)
+	contacts.mutex.Unlock()
+
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(contacts, "huekit_hue_last_contact_seconds"), 1)
+}
@@ -0,0 +1,93 @@
+package hue
+
+// hueModels Model ids of genuine hue devices with their product name.
+// The table is only used for devices, that report neither a product id
+// nor a manufacturer name, e.g. with old bridge firmwares. Add new
+// models, when they are reported as wrongly bridged.
+var hueModels = map[string]string{
+	// color lamps
+	"LCT001": "Hue color lamp",
+	"LCT002": "Hue color downlight",
+	"LCT003": "Hue color spot",
+	"LCT007": "Hue color lamp",
+	"LCT010": "Hue color lamp",
+	"LCT011": "Hue color downlight",
+	"LCT012": "Hue color candle",
+	"LCT014": "Hue color lamp",
+	"LCT015": "Hue color lamp",
+	"LCT016": "Hue color lamp",
+	"LCT024": "Hue play",
+	"LCA001": "Hue color lamp",
+	"LCA002": "Hue color lamp",
+	"LCA003": "Hue color lamp",
+	"LCB001": "Hue color downlight",
+	"LCE002": "Hue color candle",
+	"LCG002": "Hue color spot",
+	"LCS001": "Hue lily",
+	"LCF002": "Hue Calla outdoor",
+
+	// light strips and living colors
+	"LST001": "Hue lightstrip",
+	"LST002": "Hue lightstrip plus",
+	"LST003": "Hue lightstrip outdoor",
+	"LST004": "Hue lightstrip outdoor",
+	"LCL001": "Hue lightstrip plus",
+	"LLC001": "LivingColors",
+	"LLC005": "LivingColors Bloom",
+	"LLC006": "LivingColors Gen3 Iris",
+	"LLC007": "LivingColors Gen3 Bloom Aura",
+	"LLC010": "Hue Iris",
+	"LLC011": "Hue bloom",
+	"LLC012": "Hue bloom",
+	"LLC013": "Storylight",
+	"LLC014": "LivingColors Aura",
+	"LLC020": "Hue go",
+	"LLM001": "Color light module",
+
+	// white ambiance
+	"LTW001": "Hue white ambiance lamp",
+	"LTW004": "Hue white ambiance lamp",
+	"LTW010": "Hue white ambiance lamp",
+	"LTW011": "Hue white ambiance lamp",
+	"LTW012": "Hue white ambiance candle",
+	"LTW013": "Hue white ambiance spot",
+	"LTW014": "Hue white ambiance spot",
+	"LTW015": "Hue white ambiance lamp",
+	"LTA001": "Hue white ambiance lamp",
+	"LTG002": "Hue white ambiance spot",
+	"LTO001": "Hue white ambiance filament",
+	"LTC001": "Hue white ambiance ceiling",
+	"LTP001": "Hue white ambiance pendant",
+
+	// white
+	"LWB004": "Hue white lamp",
+	"LWB006": "Hue white lamp",
+	"LWB007": "Hue white lamp",
+	"LWB010": "Hue white lamp",
+	"LWB014": "Hue white lamp",
+	"LWA001": "Hue white lamp",
+	"LWA004": "Hue white filament",
+	"LWG001": "Hue white spot",
+	"LWG004": "Hue white spot",
+	"LWO001": "Hue white filament",
+	"LWV001": "Hue white filament",
+	"LWE002": "Hue white candle",
+
+	// plugs
+	"LOM001": "Hue smart plug",
+	"LOM002": "Hue smart plug",
+	"LOM004": "Hue smart plug",
+
+	// sensors and switches
+	"SML001":    "Hue motion sensor",
+	"SML002":    "Hue outdoor motion sensor",
+	"SML003":    "Hue motion sensor",
+	"SOC001":    "Hue secure contact sensor",
+	"RWL020":    "Hue dimmer switch",
+	"RWL021":    "Hue dimmer switch",
+	"RWL022":    "Hue dimmer switch",
+	"ROM001":    "Hue smart button",
+	"RDM001":    "Hue wall switch module",
+	"RDM002":    "Hue tap dial switch",
+	"ZGPSWITCH": "Hue tap switch",
+}
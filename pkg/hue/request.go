@@ -0,0 +1,110 @@
+package hue
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+type toggleResponse struct {
+	Error   *errorResp   `json:"error"`
+	Success *successResp `json:"success"`
+}
+
+// get Perform a GET request against the api of the bridge and unmarshal
+// the response body into the result
+func (b *Bridge) get(path string, result interface{}) error {
+	// perform the api request
+	res, err := b.client.Get(
+		"https://" + b.address + "/api/" + b.username + path,
+	)
+
+	// handle http errors
+	if err != nil {
+		return err
+	}
+
+	// close the response body on return in order to avoid memory
+	// leaks
+	defer res.Body.Close()
+
+	// read the body
+	bodyBytes, err := io.ReadAll(res.Body)
+
+	// handle read errors
+	if err != nil {
+		return err
+	}
+
+	// the bridge returns a list of errors instead of the requested
+	// object, e.g. when the username is unknown
+	if bytes.HasPrefix(bytes.TrimSpace(bodyBytes), []byte("[")) {
+		return parseResponseErrors(bodyBytes)
+	}
+
+	// unmarshal the json body
+	return json.Unmarshal(bodyBytes, result)
+}
+
+// put Perform a PUT request with the json encoded body against the api
+// of the bridge and return the errors from the response
+func (b *Bridge) put(path string, body interface{}) error {
+	// create the request body
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	// create the api request
+	req, err := http.NewRequest(
+		"PUT",
+		"https://"+b.address+"/api/"+b.username+path,
+		bytes.NewBuffer(bodyBytes),
+	)
+
+	// handle http errors
+	if err != nil {
+		return err
+	}
+
+	res, err := b.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	resByte, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return err
+	}
+
+	return parseResponseErrors(resByte)
+}
+
+// parseResponseErrors Parse a list of results from the bridge and
+// return the last contained error
+func parseResponseErrors(body []byte) error {
+	var toggleResp []toggleResponse
+
+	if err := json.Unmarshal(body, &toggleResp); err != nil {
+		return err
+	}
+
+	var err error
+
+	for _, res := range toggleResp {
+		if res.Error != nil {
+			err = &APIError{
+				Type:        res.Error.Type,
+				Description: res.Error.Description,
+			}
+		}
+	}
+
+	return err
+}
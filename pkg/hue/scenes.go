@@ -0,0 +1,59 @@
+package hue
+
+import (
+	"sort"
+)
+
+// Scene Represents a scene at the hue bridge
+type Scene struct {
+	ID      string
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Group   string   `json:"group"`
+	Lights  []string `json:"lights"`
+	Recycle bool     `json:"recycle"`
+}
+
+type sceneRecall struct {
+	Scene string `json:"scene"`
+}
+
+// Scenes Query and return all scenes
+func (b *Bridge) Scenes() ([]*Scene, error) {
+	// allocate the structure for the response body in memory
+	var sceneMap map[string]*Scene
+
+	// perform the api request to fetch all scenes
+	if err := b.get("/scenes", &sceneMap); err != nil {
+		return nil, err
+	}
+
+	var scenes []*Scene
+
+	for id, scene := range sceneMap {
+		// add the ID to the scene
+		scene.ID = id
+
+		scenes = append(scenes, scene)
+	}
+
+	// sort the scenes by their id in order to always return them
+	// in the same order
+	sort.Slice(scenes, func(i, j int) bool {
+		return lessID(scenes[i].ID, scenes[j].ID)
+	})
+
+	return scenes, nil
+}
+
+// RecallScene Activate a scene with a group action
+func (b *Bridge) RecallScene(scene *Scene) error {
+	// light scenes are not bound to a group, so they are recalled
+	// with the special group 0, that contains all lights
+	group := scene.Group
+	if group == "" {
+		group = "0"
+	}
+
+	return b.put("/groups/"+group+"/action", &sceneRecall{Scene: scene.ID})
+}
@@ -0,0 +1,109 @@
+package hue
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBridge_Scenes(t *testing.T) {
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`{
+  "4e1c6b20e-on-0": {
+    "name": "Relax",
+    "type": "GroupScene",
+    "group": "1",
+    "lights": ["1", "2"],
+    "recycle": false
+  },
+  "1a2b3c4d5-on-0": {
+    "name": "Reading",
+    "type": "LightScene",
+    "lights": ["3"],
+    "recycle": true
+  }
+}`))
+	}))
+	defer mockServer.Close()
+
+	bridge := &Bridge{
+		address:  strings.TrimPrefix(mockServer.URL, "https://"),
+		username: "success",
+		client:   mockServer.Client(),
+	}
+
+	scenes, err := bridge.Scenes()
+
+	assert.Nil(t, err)
+	assert.Nil(t, deep.Equal([]*Scene{
+		{
+			ID:      "1a2b3c4d5-on-0",
+			Name:    "Reading",
+			Type:    "LightScene",
+			Lights:  []string{"3"},
+			Recycle: true,
+		},
+		{
+			ID:     "4e1c6b20e-on-0",
+			Name:   "Relax",
+			Type:   "GroupScene",
+			Group:  "1",
+			Lights: []string{"1", "2"},
+		},
+	}, scenes))
+}
+
+func TestBridge_RecallScene(t *testing.T) {
+	var requestURI, requestBody string
+
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, _ := io.ReadAll(r.Body)
+
+		requestURI = r.RequestURI
+		requestBody = string(bodyBytes)
+
+		w.WriteHeader(200)
+		w.Write([]byte(`[{"success": {"/groups/1/action/scene": "4e1c6b20e-on-0"}}]`))
+	}))
+	defer mockServer.Close()
+
+	tests := []struct {
+		description  string
+		scene        *Scene
+		expectedURI  string
+		expectedBody string
+	}{
+		{
+			description:  "group scene",
+			scene:        &Scene{ID: "4e1c6b20e-on-0", Type: "GroupScene", Group: "1"},
+			expectedURI:  "/api/success/groups/1/action",
+			expectedBody: `{"scene":"4e1c6b20e-on-0"}`,
+		},
+		{
+			description:  "light scene",
+			scene:        &Scene{ID: "1a2b3c4d5-on-0", Type: "LightScene"},
+			expectedURI:  "/api/success/groups/0/action",
+			expectedBody: `{"scene":"1a2b3c4d5-on-0"}`,
+		},
+	}
+
+	for _, test := range tests {
+		bridge := &Bridge{
+			address:  strings.TrimPrefix(mockServer.URL, "https://"),
+			username: "success",
+			client:   mockServer.Client(),
+		}
+
+		err := bridge.RecallScene(test.scene)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedURI, requestURI, test.description)
+		assert.Equalf(t, test.expectedBody, requestBody, test.description)
+	}
+}
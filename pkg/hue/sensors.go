@@ -0,0 +1,108 @@
+package hue
+
+import (
+	"sort"
+)
+
+// Types of the sensors, that are bridged to homekit
+const (
+	SensorTypeZLLPresence    = "ZLLPresence"
+	SensorTypeZHAPresence    = "ZHAPresence"
+	SensorTypeZLLTemperature = "ZLLTemperature"
+	SensorTypeZHATemperature = "ZHATemperature"
+	SensorTypeZLLLightLevel  = "ZLLLightLevel"
+	SensorTypeZHALightLevel  = "ZHALightLevel"
+	SensorTypeZHAOpenClose   = "ZHAOpenClose"
+	SensorTypeZLLOpenClose   = "ZLLOpenClose"
+	SensorTypeZLLSwitch      = "ZLLSwitch"
+	SensorTypeZGPSwitch      = "ZGPSwitch"
+	SensorTypeZHASwitch      = "ZHASwitch"
+)
+
+// Sensor Represents a sensor at the hue bridge
+type Sensor struct {
+	ID               string
+	Type             string        `json:"type"`
+	Name             string        `json:"name"`
+	ModelID          string        `json:"modelid"`
+	ManufacturerName string        `json:"manufacturername"`
+	SoftwareVersion  string        `json:"swversion"`
+	ProductID        string        `json:"productid"`
+	UniqueID         string        `json:"uniqueid"`
+	State            *SensorState  `json:"state"`
+	Config           *SensorConfig `json:"config"`
+}
+
+// SensorState Represents the state of a sensor. Which fields are set
+// depends on the type of the sensor.
+type SensorState struct {
+	// ZLLPresence, ZHAPresence
+	Presence bool `json:"presence,omitempty"`
+
+	// ZLLTemperature, ZHATemperature in 0.01 °C
+	Temperature int `json:"temperature,omitempty"`
+
+	// ZLLLightLevel, ZHALightLevel as 10000 * log10(lux) + 1
+	LightLevel int  `json:"lightlevel,omitempty"`
+	Dark       bool `json:"dark,omitempty"`
+	Daylight   bool `json:"daylight,omitempty"`
+
+	// ZHAOpenClose, ZLLOpenClose
+	Open bool `json:"open,omitempty"`
+
+	// ZLLSwitch, ZGPSwitch, ZHASwitch
+	ButtonEvent int `json:"buttonevent,omitempty"`
+
+	LastUpdated string `json:"lastupdated,omitempty"`
+}
+
+// SensorConfig Represents the configuration of a sensor
+type SensorConfig struct {
+	On        bool `json:"on"`
+	Reachable bool `json:"reachable,omitempty"`
+	Battery   int  `json:"battery,omitempty"`
+}
+
+// Sensors Query and return all sensors
+func (b *Bridge) Sensors() ([]*Sensor, error) {
+	// allocate the structure for the response body in memory
+	var sensorMap map[string]*Sensor
+
+	// perform the api request to fetch all sensors
+	if err := b.get("/sensors", &sensorMap); err != nil {
+		return nil, err
+	}
+
+	var sensors []*Sensor
+
+	for id, sensor := range sensorMap {
+		// add the ID to the sensor
+		sensor.ID = id
+
+		sensors = append(sensors, sensor)
+	}
+
+	// sort the sensors by their id in order to always return them
+	// in the same order
+	sort.Slice(sensors, func(i, j int) bool {
+		return lessID(sensors[i].ID, sensors[j].ID)
+	})
+
+	return sensors, nil
+}
+
+// Sensor Query and return a sensor by its id
+func (b *Bridge) Sensor(id string) (*Sensor, error) {
+	// allocate the structure for the response body in memory
+	var sensor Sensor
+
+	// perform the api request to fetch the sensor
+	if err := b.get("/sensors/"+id, &sensor); err != nil {
+		return nil, err
+	}
+
+	// add the ID to the sensor
+	sensor.ID = id
+
+	return &sensor, nil
+}
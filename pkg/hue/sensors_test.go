@@ -0,0 +1,93 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBridge_Sensors(t *testing.T) {
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`{
+  "1": {
+    "state": {"daylight": false, "lastupdated": "2020-06-01T20:12:01"},
+    "config": {"on": true, "configured": true, "sunriseoffset": 30, "sunsetoffset": -30},
+    "name": "Daylight",
+    "type": "Daylight",
+    "modelid": "PHDL00",
+    "manufacturername": "Signify Netherlands B.V.",
+    "swversion": "1.0"
+  },
+  "12": {
+    "state": {"temperature": 2134, "lastupdated": "2020-06-01T20:10:12"},
+    "config": {"on": true, "battery": 87, "reachable": true},
+    "name": "Bathroom",
+    "type": "ZHATemperature",
+    "modelid": "lumi.weather",
+    "manufacturername": "LUMI",
+    "swversion": "20161129",
+    "uniqueid": "00:15:8d:00:01:02:03:04-01-0402"
+  },
+  "7": {
+    "state": {"open": true, "lastupdated": "2020-06-01T20:11:03"},
+    "config": {"on": true, "battery": 100, "reachable": true},
+    "name": "Front door",
+    "type": "ZHAOpenClose",
+    "modelid": "lumi.sensor_magnet.aq2",
+    "manufacturername": "LUMI",
+    "swversion": "20161128",
+    "uniqueid": "00:15:8d:00:01:02:03:05-01-0006"
+  }
+}`))
+	}))
+	defer mockServer.Close()
+
+	bridge := &Bridge{
+		address:  strings.TrimPrefix(mockServer.URL, "https://"),
+		username: "success",
+		client:   mockServer.Client(),
+	}
+
+	sensors, err := bridge.Sensors()
+
+	assert.Nil(t, err)
+	assert.Nil(t, deep.Equal([]*Sensor{
+		{
+			ID:               "1",
+			Type:             "Daylight",
+			Name:             "Daylight",
+			ModelID:          "PHDL00",
+			ManufacturerName: "Signify Netherlands B.V.",
+			SoftwareVersion:  "1.0",
+			State:            &SensorState{LastUpdated: "2020-06-01T20:12:01"},
+			Config:           &SensorConfig{On: true},
+		},
+		{
+			ID:               "7",
+			Type:             SensorTypeZHAOpenClose,
+			Name:             "Front door",
+			ModelID:          "lumi.sensor_magnet.aq2",
+			ManufacturerName: "LUMI",
+			SoftwareVersion:  "20161128",
+			UniqueID:         "00:15:8d:00:01:02:03:05-01-0006",
+			State:            &SensorState{Open: true, LastUpdated: "2020-06-01T20:11:03"},
+			Config:           &SensorConfig{On: true, Reachable: true, Battery: 100},
+		},
+		{
+			ID:               "12",
+			Type:             SensorTypeZHATemperature,
+			Name:             "Bathroom",
+			ModelID:          "lumi.weather",
+			ManufacturerName: "LUMI",
+			SoftwareVersion:  "20161129",
+			UniqueID:         "00:15:8d:00:01:02:03:04-01-0402",
+			State:            &SensorState{Temperature: 2134, LastUpdated: "2020-06-01T20:10:12"},
+			Config:           &SensorConfig{On: true, Reachable: true, Battery: 87},
+		},
+	}, sensors))
+}
@@ -47,3 +47,27 @@ func (b *Badger) Set(key, value string) error {
 		return txn.Set([]byte(key), []byte(value))
 	})
 }
+
+// Keys Return all keys of the badger db with the given prefix. Badger
+// iterates the keys in ascending order.
+func (b *Badger) Keys(prefix string) ([]string, error) {
+	keys := []string{}
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		// only the keys are needed, so the values are not fetched
+		options := badger.DefaultIteratorOptions
+		options.PrefetchValues = false
+		options.Prefix = []byte(prefix)
+
+		iterator := txn.NewIterator(options)
+		defer iterator.Close()
+
+		for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+			keys = append(keys, string(iterator.Item().KeyCopy(nil)))
+		}
+
+		return nil
+	})
+
+	return keys, err
+}
@@ -62,3 +62,37 @@ func TestBadger_Get(t *testing.T) {
 		assert.Equalf(t, test.expectedResult, result, test.description)
 	}
 }
+
+func TestBadger_Keys(t *testing.T) {
+	db, err := prepareDB(map[string]string{
+		"bridges/office/bridge_username":    "office",
+		"bridges/office/bridge_certificate": "fingerprint",
+		"bridges/lab/bridge_username":       "lab",
+		"bridge_username":                   "legacy",
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		description    string
+		prefix         string
+		expectedResult []string
+	}{
+		{
+			description:    "keys of the prefix",
+			prefix:         "bridges/office/",
+			expectedResult: []string{"bridges/office/bridge_certificate", "bridges/office/bridge_username"},
+		},
+		{
+			description:    "unknown prefix",
+			prefix:         "bridges/garage/",
+			expectedResult: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := NewBadger(db).Keys(test.prefix)
+
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, test.expectedResult, result, test.description)
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory Keep the key-value pairs in memory, e.g. in tests. They are
+// lost, when the process exits.
+type Memory struct {
+	mutex sync.Mutex
+	data  map[string]string
+}
+
+// NewMemory Instantiate a new store in memory with a copy of the
+// given key-value pairs
+func NewMemory(data map[string]string) Store {
+	m := &Memory{
+		data: map[string]string{},
+	}
+
+	for key, value := range data {
+		m.data[key] = value
+	}
+
+	return m
+}
+
+// Get Retrieve a key from memory
+func (m *Memory) Get(key string) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	value, ok := m.data[key]
+
+	// error handling
+	if !ok {
+		return "", fmt.Errorf("key %s not found", key)
+	}
+
+	return value, nil
+}
+
+// Set Save a key value pair in memory
+func (m *Memory) Set(key, value string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.data[key] = value
+
+	return nil
+}
+
+// Keys Return all keys in memory with the given prefix
+func (m *Memory) Keys(prefix string) ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keys := []string{}
+
+	for key := range m.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
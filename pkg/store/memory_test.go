@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	data := map[string]string{"bridge_username": "success"}
+
+	store := NewMemory(data)
+
+	tests := []struct {
+		description    string
+		key            string
+		expectedResult string
+		expectedError  bool
+	}{
+		{
+			description:    "initial key",
+			key:            "bridge_username",
+			expectedResult: "success",
+		},
+		{
+			description:   "unknown key",
+			key:           "bridge_certificate",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := store.Get(test.key)
+
+		assert.Equalf(t, test.expectedError, err != nil, test.description)
+		assert.Equalf(t, test.expectedResult, result, test.description)
+	}
+
+	// the initial pairs are copied
+	assert.Nil(t, store.Set("bridge_username", "updated"))
+	assert.Equal(t, "success", data["bridge_username"])
+
+	result, err := store.Get("bridge_username")
+	assert.Nil(t, err)
+	assert.Equal(t, "updated", result)
+
+	// the keys are listed in ascending order
+	assert.Nil(t, store.Set("bridge_certificate", "fingerprint"))
+
+	keys, err := store.Keys("bridge_")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bridge_certificate", "bridge_username"}, keys)
+}
@@ -0,0 +1,45 @@
+package store
+
+import "strings"
+
+// Prefix Namespace all keys of a store with a prefix, such that
+// multiple users can save the same keys in one store
+type Prefix struct {
+	store  Store
+	prefix string
+}
+
+// NewPrefix Wrap the store and prepend the prefix to all keys
+func NewPrefix(store Store, prefix string) Store {
+	return &Prefix{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+// Get Retrieve the prefixed key from the wrapped store
+func (p *Prefix) Get(key string) (string, error) {
+	return p.store.Get(p.prefix + key)
+}
+
+// Set Save the value with the prefixed key into the wrapped store
+func (p *Prefix) Set(key, value string) error {
+	return p.store.Set(p.prefix+key, value)
+}
+
+// Keys Return the keys with the prefix from the wrapped store without
+// the prefix of the namespace
+func (p *Prefix) Keys(prefix string) ([]string, error) {
+	keys, err := p.store.Keys(p.prefix + prefix)
+
+	// error handling
+	if err != nil {
+		return nil, err
+	}
+
+	for index, key := range keys {
+		keys[index] = strings.TrimPrefix(key, p.prefix)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefix(t *testing.T) {
+	db, err := prepareDB(map[string]string{
+		"bridge_username":                "legacy",
+		"bridges/office/bridge_username": "office",
+	})
+	assert.Nil(t, err)
+
+	store := NewBadger(db)
+
+	tests := []struct {
+		description    string
+		prefix         string
+		expectedResult string
+	}{
+		{
+			description:    "existing key",
+			prefix:         "bridges/office/",
+			expectedResult: "office",
+		},
+		{
+			description:    "key of another prefix",
+			prefix:         "bridges/lab/",
+			expectedResult: "",
+		},
+	}
+
+	for _, test := range tests {
+		prefixed := NewPrefix(store, test.prefix)
+
+		result, _ := prefixed.Get("bridge_username")
+		assert.Equalf(t, test.expectedResult, result, test.description)
+
+		// setting a key must not change the keys of other prefixes
+		assert.Nilf(t, prefixed.Set("bridge_username", "updated"), test.description)
+
+		result, err := store.Get("bridge_username")
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, "legacy", result, test.description)
+
+		// only the keys of the prefix are listed without it
+		keys, err := prefixed.Keys("bridge_")
+		assert.Nilf(t, err, test.description)
+		assert.Equalf(t, []string{"bridge_username"}, keys, test.description)
+	}
+}
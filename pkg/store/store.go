@@ -8,4 +8,7 @@ type Store interface {
 
 	// Set Saves a key-value relation to the store
 	Set(key, value string) error
+
+	// Keys Return all keys with the given prefix in ascending order
+	Keys(prefix string) ([]string, error)
 }